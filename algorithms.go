@@ -0,0 +1,205 @@
+package main
+
+// Generation algorithms besides the recursive backtracker. They all
+// carve a perfect maze (exactly one path between any two cells) using
+// only the maze's RNG, so a seed still names a single maze, but they
+// give it different textures: the backtracker's long, winding
+// corridors, Prim's short branchy dead ends, Kruskal's even spread of
+// short ones, Eller's rows, and Wilson's unbiased mix.
+
+// Open the entrance and exit.
+func (m *maze) openEnds() {
+	m.at(m.start).openings[north] = true
+	m.at(m.finish).openings[south] = true
+}
+
+// Randomized Prim's algorithm: grow a tree from the start, each time
+// joining a random cell on its frontier to a random cell of the tree
+// next to it.
+func (m *maze) generatePrim() {
+	defer tr(ace("generating maze (Prim's)"))
+
+	in := make(visitedMap)
+	queued := make(visitedMap)
+	var frontier []position
+	add := func(p position) {
+		in[p] = true
+		for _, dir := range []direction{north, south, east, west} {
+			np, err := dir.translate(p, m)
+			if err == nil && !in.contains(np) && !queued.contains(np) {
+				queued[np] = true
+				frontier = append(frontier, np)
+			}
+		}
+	}
+
+	add(m.start)
+	for len(frontier) > 0 {
+		i := m.rng.Intn(len(frontier))
+		p := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		for _, dir := range permutations[m.rng.Intn(len(permutations))] {
+			np, err := dir.translate(p, m)
+			if err == nil && in.contains(np) {
+				m.carve(p, dir)
+				break
+			}
+		}
+		add(p)
+	}
+
+	m.openEnds()
+}
+
+// Randomized Kruskal's algorithm: knock down the walls in a random
+// order, skipping any between cells that are already connected.
+func (m *maze) generateKruskal() {
+	defer tr(ace("generating maze (Kruskal's)"))
+
+	type wall struct {
+		p   position
+		dir direction
+	}
+	var walls []wall
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if y < m.height-1 {
+				walls = append(walls, wall{position{x, y}, south})
+			}
+			if x < m.width-1 {
+				walls = append(walls, wall{position{x, y}, east})
+			}
+		}
+	}
+	m.rng.Shuffle(len(walls), func(i, j int) { walls[i], walls[j] = walls[j], walls[i] })
+
+	// Which cells are connected, as a disjoint-set forest of cell
+	// indices.
+	parent := make([]int, len(m.cells))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	for _, w := range walls {
+		np, _ := w.dir.translate(w.p, m)
+		a, b := find(w.p.y*m.width+w.p.x), find(np.y*m.width+np.x)
+		if a != b {
+			parent[a] = b
+			m.carve(w.p, w.dir)
+		}
+	}
+
+	m.openEnds()
+}
+
+// Eller's algorithm: carve a row at a time, keeping track only of
+// which cells of the current row are connected. Neighbors in different
+// sets are joined at random, then each set carries on down into the
+// next row at least once; the last row joins every set that's left.
+func (m *maze) generateEller() {
+	defer tr(ace("generating maze (Eller's)"))
+
+	sets := make([]int, m.width)
+	next := 1
+	for y := 0; y < m.height; y++ {
+		for x := range sets {
+			if sets[x] == 0 {
+				sets[x] = next
+				next++
+			}
+		}
+
+		last := y == m.height-1
+		for x := 0; x < m.width-1; x++ {
+			if sets[x] == sets[x+1] || (!last && m.rng.Intn(2) == 0) {
+				continue
+			}
+			m.carve(position{x, y}, east)
+			old := sets[x+1]
+			for i := range sets {
+				if sets[i] == old {
+					sets[i] = sets[x]
+				}
+			}
+		}
+		if last {
+			break
+		}
+
+		// The cells of each set, sets in the order they first appear.
+		var order []int
+		members := make(map[int][]int)
+		for x, s := range sets {
+			if _, ok := members[s]; !ok {
+				order = append(order, s)
+			}
+			members[s] = append(members[s], x)
+		}
+
+		below := make([]int, m.width)
+		for _, s := range order {
+			xs := members[s]
+			m.rng.Shuffle(len(xs), func(i, j int) { xs[i], xs[j] = xs[j], xs[i] })
+			for i, x := range xs {
+				if i == 0 || m.rng.Intn(2) == 0 {
+					m.carve(position{x, y}, south)
+					below[x] = s
+				}
+			}
+		}
+		sets = below
+	}
+
+	m.openEnds()
+}
+
+// Wilson's algorithm: from each cell not yet in the maze, take a
+// random walk until it reaches the maze, forgetting any loops it makes
+// along the way, and carve the walk's path. Every perfect maze is as
+// likely as every other.
+func (m *maze) generateWilson() {
+	defer tr(ace("generating maze (Wilson's)"))
+
+	in := make(visitedMap)
+	in[m.start] = true
+
+	// The way the walk last left each cell; following it from the
+	// walk's first cell gives the walk with its loops erased.
+	exits := make(map[position]direction)
+
+	for _, i := range m.rng.Perm(len(m.cells)) {
+		first := position{x: i % m.width, y: i / m.width}
+		if in.contains(first) {
+			continue
+		}
+
+		for p := first; !in.contains(p); {
+			dirs := permutations[m.rng.Intn(len(permutations))]
+			for _, dir := range dirs {
+				if np, err := dir.translate(p, m); err == nil {
+					exits[p] = dir
+					p = np
+					break
+				}
+			}
+		}
+
+		for p := first; !in.contains(p); {
+			dir := exits[p]
+			m.carve(p, dir)
+			in[p] = true
+			p, _ = dir.translate(p, m)
+		}
+	}
+
+	m.openEnds()
+}
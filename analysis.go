@@ -0,0 +1,208 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Measurements of a maze's shape, for difficulty metrics and for
+// placing the start and finish far apart.
+type analysis struct {
+	diameter    int         // Longest shortest path between any two cells (in steps)
+	farthest    [2]position // A pair of cells that far apart
+	averagePath float64     // Mean shortest path over all pairs of cells (in steps)
+
+	// How hard the maze is to solve, for grading puzzles.
+	solution      int   // Length of the solution (in cells)
+	difficulty    int   // Decision points along it; see difficulty
+	deadEnds      int   // Cells with one way out
+	junctions     int   // Cells with three or more ways out
+	longestBranch int   // Farthest any cell is from the solution (in steps), the longest false branch
+	distances     []int // Steps from the start to each cell, indexed like m.cells; -1 if unreachable
+}
+
+// A difficulty score for the given solution: the number of decision
+// points along it, where a solver could take a wrong turn.
+func (m *maze) difficulty(path []position) int {
+	n := 0
+	for _, p := range path {
+		if p != m.finish && m.branches(p) >= 3 {
+			n++
+		}
+	}
+	return n
+}
+
+// The number of passages between cells.
+func (m *maze) passages() int {
+	n := 0
+	for i := range m.cells {
+		pos := position{x: i % m.width, y: i / m.width}
+		for _, dir := range []direction{south, east} {
+			if _, err := dir.translate(pos, m); err == nil && m.cells[i].openings[dir] {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// The cell furthest from the given one, and how far it is.
+func (m *maze) farthestFrom(from position) (position, int) {
+	far, most := from, 0
+	for i, d := range m.distances(from) {
+		if d > most {
+			far, most = position{x: i % m.width, y: i / m.width}, d
+		}
+	}
+	return far, most
+}
+
+// Most cells in a maze with loops searched from every cell; see
+// analyze. Searching a maze this big takes about half a second.
+const maxAllPairsCells = 2500
+
+// Analyze the maze. A perfect maze is a tree, which lets us find the
+// diameter with two breadth-first searches and the average path length
+// from subtree sizes: each passage lies on the path between every cell
+// on one side of it and every cell on the other. Mazes with loops fall
+// back to searching from every cell, which is quadratic in the number
+// of cells, so those bigger than maxAllPairsCells are measured as the
+// tree a walk from the start finds in them instead. That's close for
+// mazes with few loops, like the backtracker's, which closes one at
+// the start, but overstates the average path of heavily braided ones.
+func (m *maze) analyze() analysis {
+	defer tr(ace("analyzing maze"))
+
+	var result analysis
+	if m.passages() != len(m.cells)-1 && len(m.cells) <= maxAllPairsCells {
+		result = m.analyzeAllPairs()
+	} else {
+		result = m.analyzeTree()
+	}
+	m.grade(&result)
+	return result
+}
+
+// Fill in how hard the maze is to solve, which is quick even for mazes
+// with loops. Dead ends and junctions are counted over the whole maze,
+// not just along the solution, and a breadth-first search out from
+// every cell of the solution at once finds how far the false branches
+// reach.
+func (m *maze) grade(result *analysis) {
+	path := m.solution()
+	result.solution = len(path)
+	result.difficulty = m.difficulty(path)
+	for i := range m.cells {
+		p := position{x: i % m.width, y: i / m.width}
+		switch b := m.branches(p); {
+		case !m.inMask(p):
+		case b == 1:
+			result.deadEnds++
+		case b >= 3:
+			result.junctions++
+		}
+	}
+	for _, d := range m.distancesFrom(path) {
+		if d > result.longestBranch {
+			result.longestBranch = d
+		}
+	}
+	result.distances = m.distances(m.start)
+}
+
+func (m *maze) analyzeTree() analysis {
+	a, _ := m.farthestFrom(m.start)
+	b, diameter := m.farthestFrom(a)
+	result := analysis{diameter: diameter, farthest: [2]position{a, b}}
+
+	// Walk the tree depth first from the start, then total up subtree
+	// sizes in reverse order so children come before their parents.
+	n := len(m.cells)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = -1
+	}
+	root := m.start.y*m.width + m.start.x
+	parent[root] = root
+	order := make([]int, 0, n)
+	stack := []int{root}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, i)
+		pos := position{x: i % m.width, y: i / m.width}
+		for _, dir := range []direction{north, south, east, west} {
+			np, err := dir.translate(pos, m)
+			j := np.y*m.width + np.x
+			if err != nil || !m.cells[i].openings[dir] || parent[j] >= 0 {
+				continue
+			}
+			parent[j] = i
+			stack = append(stack, j)
+		}
+	}
+
+	size := make([]int, n)
+	total := 0
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		size[i]++
+		if i != root {
+			size[parent[i]] += size[i]
+			total += size[i] * (n - size[i])
+		}
+	}
+
+	if pairs := n * (n - 1) / 2; pairs > 0 {
+		result.averagePath = float64(total) / float64(pairs)
+	}
+	return result
+}
+
+func (m *maze) analyzeAllPairs() analysis {
+	var result analysis
+	total, pairs := 0, 0
+	for i := range m.cells {
+		from := position{x: i % m.width, y: i / m.width}
+		for j, d := range m.distances(from) {
+			if j <= i || d < 0 {
+				continue
+			}
+			total += d
+			pairs++
+			if d > result.diameter {
+				result.diameter = d
+				result.farthest = [2]position{from, {x: j % m.width, y: j / m.width}}
+			}
+		}
+	}
+
+	if pairs > 0 {
+		result.averagePath = float64(total) / float64(pairs)
+	}
+	return result
+}
+
+// Whether to draw the distance heatmap.
+var showHeatmap = false
+
+// Shade each cell by its distance from the start, from blue near it to
+// red at the farthest, as the flood fill's waves end up. Only the
+// raster renderer draws it.
+func (m *maze) drawHeatmap(img draw.Image, distances []int) {
+	farthest := 0
+	for _, d := range distances {
+		if d > farthest {
+			farthest = d
+		}
+	}
+	if farthest == 0 {
+		return
+	}
+	for i, d := range distances {
+		if d >= 0 {
+			m.fillCell(img, position{x: i % m.width, y: i / m.width}, image.NewUniform(waveColor(float64(d)/float64(farthest))))
+		}
+	}
+}
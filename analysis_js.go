@@ -0,0 +1,102 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+)
+
+// The JS side of the analysis API; the measurements are in
+// analysis.go.
+
+var badPosition = errors.New("position must be an {x, y} object")
+
+func positionToJS(p position) js.Value {
+	v := js.Global().Get("Object").New()
+	v.Set("x", p.x)
+	v.Set("y", p.y)
+	return v
+}
+
+func pathToJS(path []position) js.Value {
+	cells := js.Global().Get("Array").New()
+	for _, p := range path {
+		cells.Call("push", positionToJS(p))
+	}
+	return cells
+}
+
+// Read a cell of the current maze from a JS {x, y} object.
+func positionFromJS(v js.Value) (position, error) {
+	if v.Type() != js.TypeObject || v.Get("x").Type() != js.TypeNumber || v.Get("y").Type() != js.TypeNumber {
+		return position{}, badPosition
+	}
+	p := position{x: v.Get("x").Int(), y: v.Get("y").Int()}
+	if p.x < 0 || p.y < 0 || p.x >= currentMaze.width || p.y >= currentMaze.height {
+		return position{}, outOfBounds
+	}
+	return p, nil
+}
+
+// analyze() returns the current maze's analysis, or null if there's
+// no maze.
+func analyzeJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil {
+		return js.Null()
+	}
+	a := currentMaze.analyze()
+	result := js.Global().Get("Object").New()
+	result.Set("diameter", a.diameter)
+	result.Set("farthest", js.Global().Get("Array").New(positionToJS(a.farthest[0]), positionToJS(a.farthest[1])))
+	result.Set("averagePath", a.averagePath)
+	result.Set("solution", a.solution)
+	result.Set("difficulty", a.difficulty)
+	result.Set("deadEnds", a.deadEnds)
+	result.Set("junctions", a.junctions)
+	result.Set("longestBranch", a.longestBranch)
+	distances := make([]interface{}, len(a.distances))
+	for i, d := range a.distances {
+		distances[i] = d
+	}
+	result.Set("distances", distances)
+	return result
+}
+
+// findPath({x, y}, {x, y}) returns the cells along a shortest path
+// between the two, inclusive, or null if there's none.
+func findPathJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil || len(args) != 2 {
+		return js.Null()
+	}
+	from, err := positionFromJS(args[0])
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+	to, err := positionFromJS(args[1])
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+
+	path := currentMaze.findPath(from, to)
+	if path == nil {
+		return js.Null()
+	}
+	return pathToJS(path)
+}
+
+// Build the JS-facing analysis API.
+func analysisControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	analyze := js.FuncOf(analyzeJS)
+	controls.Set("analyze", analyze)
+
+	findPath := js.FuncOf(findPathJS)
+	controls.Set("findPath", findPath)
+
+	return controls, []js.Func{analyze, findPath}
+}
@@ -0,0 +1,251 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// An animation draws a sequence of steps onto the frame buffer over
+// time. Every time the browser is ready to repaint, we draw however
+// many steps should have been drawn by now and export a frame.
+//
+// Animations can be paused, stepped, and sought through; seeking
+// backwards redraws the animation from the beginning.
+type animation struct {
+	steps    int       // Number of steps in the animation
+	drawn    int       // Number of steps drawn so far
+	duration float64   // Total running time at normal speed (in milliseconds)
+	progress float64   // Steps that should have been drawn so far, fractionally
+	last     float64   // Timestamp of the previous frame, zero if none
+	paused   bool      // Whether we're waiting to be resumed
+	reset    func()    // Redraw the image as it was before the first step
+	step     func(int) // Draw a single step
+	render   func()    // Export a frame after drawing steps
+	request  js.Value  // Our pending requestAnimationFrame, if any
+	frame    js.Func   // Our requestAnimationFrame callback
+}
+
+// The current animation, if any. It stays current after it finishes
+// so that it can still be sought through.
+var running *animation = nil
+
+// Pacing, shared by all animations and adjustable while they run.
+// The speed multiplies how fast animations advance. If framesPerStep
+// is set, animations advance one step every that many frames (at
+// normal speed) instead of spreading their steps over their duration.
+var (
+	animationSpeed float64 = 1
+	framesPerStep  float64 = 0
+)
+
+// If set, a JS function called after every animation frame has been
+// exported to the canvas, so that pages can capture frames in step
+// with the animation (e.g. with a captureStream(0) track's
+// requestFrame() and a MediaRecorder).
+var frameHook js.Value = js.Undefined()
+
+func newAnimation(steps int, duration float64, reset func(), step func(int), render func()) *animation {
+	a := &animation{
+		steps:    steps,
+		duration: duration,
+		reset:    reset,
+		step:     step,
+		render:   render,
+	}
+	a.frame = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		a.request = js.Undefined()
+		if !a.paused {
+			a.tick(args[0].Float())
+		}
+		return nil
+	})
+	return a
+}
+
+// Stop whatever is running and start the given animation.
+func animate(a *animation) {
+	stopAnimation()
+	running = a
+	a.requestFrame()
+}
+
+// Stop the current animation, if any, where it is.
+func stopAnimation() {
+	if running == nil {
+		return
+	}
+	running.cancelFrame()
+	running.frame.Release()
+	running = nil
+}
+
+// Export a frame and tell the frame hook about it.
+func (a *animation) show() {
+	a.render()
+	if frameHook.Type() == js.TypeFunction {
+		frameHook.Invoke(map[string]interface{}{
+			"steps": a.steps,
+			"drawn": a.drawn,
+			"done":  a.drawn >= a.steps,
+		})
+	}
+}
+
+func (a *animation) requestFrame() {
+	if a.request.IsUndefined() && a.drawn < a.steps {
+		a.request = js.Global().Call("requestAnimationFrame", a.frame)
+	}
+}
+
+func (a *animation) cancelFrame() {
+	if !a.request.IsUndefined() {
+		js.Global().Call("cancelAnimationFrame", a.request)
+		a.request = js.Undefined()
+	}
+}
+
+// Draw a frame at the given timestamp.
+func (a *animation) tick(now float64) {
+	switch {
+	case framesPerStep > 0:
+		a.progress += animationSpeed / framesPerStep
+	case a.duration <= 0:
+		a.progress = float64(a.steps)
+	case a.last != 0:
+		a.progress += (now - a.last) * animationSpeed * float64(a.steps) / a.duration
+	}
+	a.last = now
+
+	a.advance(int(a.progress))
+	a.show()
+
+	a.requestFrame()
+}
+
+// Draw steps until the given number have been drawn.
+func (a *animation) advance(target int) {
+	for ; a.drawn < target && a.drawn < a.steps; a.drawn++ {
+		a.step(a.drawn)
+	}
+}
+
+// Keep our progress in step with the frame when we move by hand.
+func (a *animation) sync() {
+	a.progress = float64(a.drawn)
+	a.last = 0
+}
+
+func (a *animation) pause() {
+	a.paused = true
+	a.cancelFrame()
+}
+
+func (a *animation) resume() {
+	a.paused = false
+	a.last = 0
+	a.requestFrame()
+}
+
+// Pause, and then draw the next step.
+func (a *animation) stepForward() {
+	a.pause()
+	a.advance(a.drawn + 1)
+	a.sync()
+	a.show()
+}
+
+// Move to just after the given step, redrawing from the beginning if
+// it's already been drawn.
+func (a *animation) seek(target int) {
+	if target < 0 {
+		target = 0
+	}
+	if target < a.drawn {
+		a.reset()
+		a.drawn = 0
+	}
+	a.advance(target)
+	a.sync()
+	a.show()
+	if !a.paused {
+		a.requestFrame()
+	}
+}
+
+// Build the JS-facing animation controls; they act on whichever
+// animation is current.
+func animationControls() (js.Value, []js.Func) {
+	var funcs []js.Func
+	controls := js.Global().Get("Object").New()
+	register := func(name string, fn func(a *animation, args []js.Value) interface{}) {
+		f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			if running == nil {
+				return nil
+			}
+			return fn(running, args)
+		})
+		funcs = append(funcs, f)
+		controls.Set(name, f)
+	}
+
+	register("pause", func(a *animation, args []js.Value) interface{} {
+		a.pause()
+		return nil
+	})
+	register("resume", func(a *animation, args []js.Value) interface{} {
+		a.resume()
+		return nil
+	})
+	register("step", func(a *animation, args []js.Value) interface{} {
+		a.stepForward()
+		return nil
+	})
+	register("seek", func(a *animation, args []js.Value) interface{} {
+		if len(args) > 0 {
+			if target, ok := finiteArg(args[0]); ok {
+				a.seek(int(math.Max(0, math.Min(target, float64(a.steps)))))
+			}
+		}
+		return nil
+	})
+	register("state", func(a *animation, args []js.Value) interface{} {
+		return map[string]interface{}{
+			"steps":  a.steps,
+			"drawn":  a.drawn,
+			"paused": a.paused,
+		}
+	})
+
+	// Pacing applies to every animation, so these work whether or not
+	// one is running.
+	setting := func(name string, fn func(float64)) {
+		f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			if len(args) > 0 && args[0].Type() == js.TypeNumber {
+				if v := args[0].Float(); !math.IsNaN(v) && v >= 0 {
+					fn(v)
+				}
+			}
+			return nil
+		})
+		funcs = append(funcs, f)
+		controls.Set(name, f)
+	}
+
+	setting("setSpeed", func(v float64) { animationSpeed = v })
+	setting("setFramesPerStep", func(v float64) { framesPerStep = v })
+
+	onFrame := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		frameHook = js.Undefined()
+		if len(args) > 0 {
+			frameHook = args[0]
+		}
+		return nil
+	})
+	funcs = append(funcs, onFrame)
+	controls.Set("onFrame", onFrame)
+
+	return controls, funcs
+}
@@ -0,0 +1,211 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"syscall/js"
+	"unsafe"
+)
+
+// The stable JS API is the MazeGen object. Every method's TypeScript
+// signature is declared right beside it here, and cmd/mazetypes reads
+// these tables to write mazegen.d.ts, so typed bindings can't drift
+// from the Go. The older per-feature objects (mazeAnimation, mazeGame,
+// and so on) are still there for pages that use them.
+
+// A type used in MazeGen's signatures.
+type apiType struct {
+	name       string
+	definition string // TypeScript
+	doc        string
+}
+
+// A method of the MazeGen object.
+type apiMethod struct {
+	name      string
+	signature string // TypeScript, from the parameter list on
+	doc       string
+	fn        func(this js.Value, args []js.Value) interface{}
+}
+
+var apiTypes = []apiType{
+	{name: "MazeInitOptions", definition: "{ reserveMemory?: boolean }", doc: "Options for init; reserveMemory sets aside the largest frame buffer up front, so memory never grows while drawing."},
+	{name: "MazeCell", definition: "{ x: number; y: number }", doc: "A cell of the maze, counting from zero at the top left."},
+	{name: "MazeAnalysis", definition: "{ diameter: number; farthest: [MazeCell, MazeCell]; averagePath: number; solution: number; difficulty: number; deadEnds: number; junctions: number; longestBranch: number; distances: number[] }", doc: "The shape of a maze, and how hard it is: the solution's length in cells, its decision points, the maze's dead ends and junctions, and how far its longest false branch runs from the solution. Distances are in steps between cells; distances holds each cell's from the start, indexed like MazeData's cells, with -1 for cells that can't be reached."},
+	{name: "MazeExportFormat", definition: `"png" | "svg" | "pdf" | "hpgl" | "brf" | "tactile" | "thermal" | "pbm" | "apng" | "layer" | "stitch" | "bricks" | "cutting" | "openscad" | "poster" | "html" | "xlsx"`, doc: "The formats the current maze can be exported in."},
+	{name: "MazeData", definition: "{ width: number; height: number; imageWidth: number; imageHeight: number; cells: Uint8Array; start: MazeCell; finish: MazeCell; solution: MazeCell[] | null; label: string }", doc: "A maze as handed to custom renderers. Cells run left to right, top to bottom; each is a bit mask of its openings: 1 north, 2 south, 4 east, 8 west."},
+	{name: "MazeOptions", definition: "{ height?: number; width?: number; levels?: number; seed?: number; algorithm?: string; solution?: boolean; label?: boolean; solutions?: number; routes?: number; animate?: boolean; duration?: number; [setting: string]: string | number | boolean | undefined }", doc: "Settings for generate, overriding the page's; any setting can also be named by its input's ID. Settings neither gives take the settings form's defaults."},
+	{name: "MazeResult", definition: "{ id: string | null; seed: number; algorithm: string; width: number; height: number; imageWidth: number; imageHeight: number; solution: MazeCell[] | null; pixels?: number; length?: number }", doc: "A newly generated maze; id is null if it was changed after generation, as for MazeGen.id. If it was drawn into the RGBA frame buffer, pixels is the buffer's offset in the module's memory and length its size in bytes, good until the next maze is drawn."},
+	{name: "MazeRenderer", definition: "(maze: MazeData, canvas: HTMLCanvasElement) => ImageData | Uint8ClampedArray | void", doc: "A custom renderer: draw on the canvas and return nothing, or return RGBA pixels of the image size to be put on the canvas."},
+	{name: "MazePNGInfo", definition: "{ id: string; algorithm: string; difficulty: number; decoys: number; solutions: number; braid: number }", doc: "The maze parameters carried in an exported PNG."},
+	{name: "MazeBatchOptions", definition: `{ unique?: boolean; formats?: ("png" | "svg" | "pdf")[] }`, doc: "Options for batchZip; unique skips near-duplicate mazes, and formats defaults to all three."},
+	{name: "MazePrintOptions", definition: `{ paper?: "letter" | "a4" | ""; lineWidth?: number; marks?: boolean; bleed?: number }`, doc: "Options for print, overriding the page's; paper lays the maze out on a sheet of that size, lineWidth is the width of walls in millimetres, and marks and bleed (in millimetres) add print marks to PDFs."},
+	{name: "MazeHistoryEntry", definition: "{ id: string | null; code: string; decoys: number; solutions: number; braid: number; thumbnail: ImageData }", doc: "A recently generated maze, with its maze code, and a thumbnail at most 128 pixels on a side; id is null if it was changed after generation, as for MazeGen.id."},
+	{name: "MazeCellEvent", definition: `{ type: "hover" | "click" | "leave"; cell?: MazeCell; walls?: { north: boolean; south: boolean; east: boolean; west: boolean }; start?: boolean; finish?: boolean }`, doc: "The pointer moving onto, clicking, or leaving the maze's cells; walls are true where the cell has one. Leave events have only a type."},
+	{name: "MazeExplainStep", definition: `{ index: number; steps: number; type: "carve" | "backtrack" | "done"; text: string; at: MazeCell; to: MazeCell; depth: number; direction?: "north" | "south" | "east" | "west" }`, doc: "A step of generation replayed in explain mode, described in text; depth is the stack's depth after it. A step seen again after seeking backwards has the same index."},
+	{name: "MazeGameStatus", definition: "{ at: MazeCell; moves: number; time: number; finished: boolean; failed: boolean }", doc: "How a game is going: where the player is, how many moves they've made, and for how long they've played (or did), in milliseconds; failed is true if they ran out of moves."},
+	{name: "MazeViewport", definition: "{ x: number; y: number; zoom: number; width: number; height: number; imageWidth: number; imageHeight: number }", doc: "The part of a maze too big to draw whole that the canvas shows: the point of the maze's image (in image pixels) at the canvas's top left, how many canvas pixels there are to an image pixel, and the sizes of the canvas and of the whole image."},
+	{name: "MazeMemStats", definition: "{ heapInUse: number; heapAlloc: number; sys: number; gcCycles: number; lastPause: number }", doc: "Go runtime memory statistics, in bytes; lastPause is in milliseconds."},
+}
+
+var apiMethods = []apiMethod{
+	{name: "init", signature: "(canvas: HTMLCanvasElement | string, options?: MazeInitOptions): string | null", doc: "Attach to the page, drawing on the given canvas (or the first matching the selector); null on success, otherwise why not.", fn: initJS},
+	{name: "dispose", signature: "(): void", doc: "Detach from the page, stopping anything in progress and freeing the current maze.", fn: disposeJS},
+	{name: "shutdown", signature: "(): void", doc: "Dispose, then remove MazeGen and the other global objects and stop the Go program, so the module can be replaced.", fn: shutdownJS},
+	{name: "generate", signature: "(options?: MazeOptions): MazeResult | null", doc: "Generate and draw a new maze with the page's current settings, overridden by any options given; null if the settings won't do, or the maze isn't a single floor of squares.", fn: generateJS},
+	{name: "encode", signature: "(): string | null", doc: "A code carrying the current maze, walls and all, short enough for a URL; null if there's no maze or it isn't made of squares.", fn: encodeJS},
+	{name: "decode", signature: "(code: string, options?: MazeOptions): MazeResult | null", doc: "Draw the maze a code carries and make it the current maze, with the page's settings overridden by any options given (those saying how to draw it, and whether to show the solution, matter; those saying how to generate it don't); null if the code is malformed.", fn: decodeJS},
+	{name: "solve", signature: "(): MazeCell[] | null", doc: "The solution of the current maze, from start to finish, or null if there's no maze.", fn: solveJS},
+	{name: "solutionPath", signature: "(): string | null", doc: "SVG path data for the solution of the current maze, through the centers of its cells in image pixels, or null if there's no maze.", fn: solutionPathJS},
+	{name: "print", signature: `(format: "svg" | "pdf", options?: MazePrintOptions): Blob | null`, doc: "The current maze as a print-quality vector file, with its solution if it's showing, or in PDFs on a layer that starts hidden; null if there's no maze, it's too big to draw whole, or the format is unknown.", fn: printJS},
+	{name: "export", signature: "(format: MazeExportFormat): boolean", doc: "Offer the current maze as a download in the given format, unless it's too big to draw whole; false if the format is unknown.", fn: exportJS},
+	{name: "analyze", signature: "(): MazeAnalysis | null", doc: "Analyze the current maze.", fn: analyzeJS},
+	{name: "findPath", signature: "(from: MazeCell, to: MazeCell): MazeCell[] | null", doc: "A shortest path between two cells of the current maze, or null if there's none.", fn: findPathJS},
+	{name: "id", signature: "(): string | null", doc: "The ID of the current maze, for verify; null if it was changed after generation (by decoys, braiding, extra solutions, or a mask), when verify takes its code (see encode) instead.", fn: mazeIDJS},
+	{name: "verify", signature: "(id: string, moves: string): string | null", doc: "Check that moves (N, S, E, and W) solve the maze with the given ID or code; null if they do, otherwise why not.", fn: verifyJS},
+	{name: "predict", signature: "(params: { height: number; width: number; algorithm?: string }): number | null", doc: "Estimate how long generating and drawing a maze will take, in milliseconds.", fn: predictJS},
+	{name: "seeds", signature: "(count: number, unique?: boolean): number[] | null", doc: "Seeds for a batch of mazes with the current settings, optionally skipping near-duplicates.", fn: batchSeedsJS},
+	{name: "batchZip", signature: "(count: number, options?: MazeBatchOptions): Blob | null", doc: "A ZIP archive of a batch of mazes with the current settings, each as a puzzle and a key.", fn: batchZipJS},
+	{name: "history", signature: "(): MazeHistoryEntry[]", doc: "The mazes generated most recently, newest first.", fn: historyJS},
+	{name: "onHistory", signature: "(callback: ((entries: MazeHistoryEntry[]) => void) | null): void", doc: "Call back with the history whenever a maze is generated; null stops.", fn: onHistoryJS},
+	{name: "restore", signature: "(index: number): boolean", doc: "Draw the maze at the given place in the history again, just as it was, and put back its settings; false if there's none there.", fn: restoreJS},
+	{name: "onCell", signature: "(callback: ((event: MazeCellEvent) => void) | null): void", doc: "Call back as the pointer moves onto, clicks, or leaves the maze's cells; null stops.", fn: onCellJS},
+	{name: "view", signature: "(viewport?: { x?: number; y?: number; zoom?: number }): MazeViewport | null", doc: "The viewport the current maze is drawn through, moved first to whatever part of it is given; null if the maze is small enough to be drawn whole. The viewport is kept on the maze's image.", fn: viewJS},
+	{name: "pan", signature: "(dx: number, dy: number): MazeViewport | null | false", doc: "Move the viewport by the given number of canvas pixels, and return it; null if there's none, and false if dx or dy isn't a finite number.", fn: panJS},
+	{name: "zoom", signature: "(factor: number, x?: number, y?: number): MazeViewport | null | false", doc: "Zoom the viewport by the given factor, keeping the point under the given canvas pixel (by default, the middle) where it is, and return it; null if there's none, and false if factor isn't a positive finite number or x and y aren't finite numbers.", fn: zoomJS},
+	{name: "gameStatus", signature: "(): MazeGameStatus | null", doc: "How the game in play mode is going, or null if there's none.", fn: gameStatusJS},
+	{name: "onMove", signature: "(callback: ((status: MazeGameStatus) => void) | null): void", doc: "Call back with the game's status every time the player moves; null stops.", fn: onMoveJS},
+	{name: "onExplain", signature: "(callback: ((step: MazeExplainStep) => void) | null): void", doc: "Call back with every step explain mode replays; null stops.", fn: onExplainJS},
+	{name: "setMask", signature: "(mask: string | ImageData | null): string | null", doc: "Shape new mazes to a mask, drawn as text (spaces, dots, and zeros outside the shape) or given as an image (dark pixels inside), stretched over the maze; null goes back to rectangles. Masked mazes are only carved with the recursive backtracker, and generating one with another algorithm fails. Returns null on success, otherwise why not.", fn: setMaskJS},
+	{name: "registerRenderer", signature: "(name: string, render: MazeRenderer | null): void", doc: "Register a custom renderer, chosen by setting the renderer to its name; null removes it.", fn: registerRendererJS},
+	{name: "readPNG", signature: "(data: Uint8Array | ArrayBuffer): MazePNGInfo | null", doc: "The maze parameters in an exported PNG, or null if it has none.", fn: readPNGJS},
+	{name: "loadPNG", signature: "(data: Uint8Array | ArrayBuffer): boolean", doc: "Set the page's settings from an exported PNG and generate its maze again; false if it has no parameters.", fn: loadPNGJS},
+	{name: "exportSettings", signature: "(): string | null", doc: "The page's settings, all but the seed, as a JSON profile; null if the page has no settings form.", fn: exportSettingsJS},
+	{name: "importSettings", signature: "(json: string): string | null", doc: "Set the page's settings from a JSON profile and, if there's a maze, generate a new one; null on success, otherwise why not.", fn: importSettingsJS},
+	{name: "memStats", signature: "(): MazeMemStats", doc: "Go runtime memory statistics.", fn: memStatsJS},
+}
+
+// Exporters by format name, for MazeGen.export.
+var exporters = map[string]func(){
+	"png":      exportPNGCallback,
+	"svg":      exportSVGCallback,
+	"pdf":      exportPDFCallback,
+	"hpgl":     exportHPGLCallback,
+	"brf":      exportBRFCallback,
+	"tactile":  exportTactileCallback,
+	"thermal":  exportThermalCallback,
+	"pbm":      exportPBMCallback,
+	"apng":     exportAPNGCallback,
+	"layer":    exportLayerCallback,
+	"stitch":   exportStitchCallback,
+	"bricks":   exportBricksCallback,
+	"cutting":  exportCuttingCallback,
+	"openscad": exportOpenSCADCallback,
+	"poster":   exportPosterCallback,
+	"html":     exportHTMLCallback,
+	"xlsx":     exportXLSXCallback,
+}
+
+func generateJS(this js.Value, args []js.Value) interface{} {
+	s := pageSettings
+	if len(args) > 0 {
+		s = settingSource{args[0]}
+	}
+	m := generateFrom(s)
+	if m == nil {
+		return js.Null()
+	}
+	return mazeResult(m)
+}
+
+// The current maze, m, as a MazeResult.
+func mazeResult(m *maze) map[string]interface{} {
+	imageWidth, imageHeight := m.imageSize()
+	result := map[string]interface{}{
+		"id":          currentIDJS(),
+		"seed":        currentSeed,
+		"algorithm":   m.algorithm,
+		"width":       m.width,
+		"height":      m.height,
+		"imageWidth":  imageWidth,
+		"imageHeight": imageHeight,
+		"solution":    js.Null(),
+	}
+	if currentSolution != nil {
+		result["solution"] = pathToJS(currentSolution)
+	}
+	if fb, ok := frameBuffer.(*image.RGBA); ok && frameBufferMaze == m {
+		result["pixels"] = uintptr(unsafe.Pointer((*[1]uint8)(fb.Pix)))
+		result["length"] = len(fb.Pix)
+	}
+	return result
+}
+
+func solveJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil {
+		return js.Null()
+	}
+	path := currentSolution
+	if path == nil {
+		path = currentMaze.solution()
+	}
+	return pathToJS(path)
+}
+
+func solutionPathJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil {
+		return js.Null()
+	}
+	path := currentSolution
+	if path == nil {
+		path = currentMaze.solution()
+	}
+	return currentMaze.svgPathData(path)
+}
+
+func printJS(this js.Value, args []js.Value) interface{} {
+	if !exportable() || len(args) == 0 {
+		return js.Null()
+	}
+	s := pageSettings
+	if len(args) > 1 {
+		s = settingSource{args[1]}
+	}
+
+	var data interface{}
+	var mime string
+	switch args[0].String() {
+	case "svg":
+		data, mime = currentMaze.svgPage(currentSolution, printStyle(), pageSetupSetting(s)), "image/svg+xml"
+	case "pdf":
+		data, mime = bytesToJS(currentMaze.pdf(currentMaze.solution(), currentLabel, printMarksSetting(s), pageSetupSetting(s))), "application/pdf"
+	default:
+		return js.Null()
+	}
+	return js.Global().Get("Blob").New([]interface{}{data}, map[string]interface{}{"type": mime})
+}
+
+func exportJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 {
+		return false
+	}
+	export, ok := exporters[args[0].String()]
+	if ok {
+		export()
+	}
+	return ok
+}
+
+// Build the MazeGen object.
+func apiControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	var funcs []js.Func
+	for _, m := range apiMethods {
+		f := js.FuncOf(m.fn)
+		controls.Set(m.name, f)
+		funcs = append(funcs, f)
+	}
+	return controls, funcs
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+)
+
+const apngMaxFrames = 100 // Most frames in an exported animation; steps are grouped to fit
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var badPNG = errors.New("unexpected png encoding")
+
+// An animated PNG under construction. Frames after the first only
+// cover the region that changed, drawn over the previous frame, so
+// each one costs about as much as the bit of solution it adds.
+type apng struct {
+	frames   int
+	sequence uint32
+	header   []byte // IHDR from the first frame; later frames must match
+	body     bytes.Buffer
+	encoder  png.Encoder
+}
+
+// Split a PNG into its chunks, returning the IHDR data and all of the
+// IDAT data, concatenated.
+func pngChunks(data []byte) (header, idat []byte, err error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, nil, badPNG
+	}
+	data = data[len(pngSignature):]
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		if uint64(len(data)) < 12+uint64(length) {
+			return nil, nil, badPNG
+		}
+		kind, chunk := string(data[4:8]), data[8:8+length]
+		switch kind {
+		case "IHDR":
+			header = chunk
+		case "IDAT":
+			idat = append(idat, chunk...)
+		}
+		data = data[12+length:]
+	}
+	if header == nil {
+		return nil, nil, badPNG
+	}
+	return header, idat, nil
+}
+
+func writeChunk(b *bytes.Buffer, kind string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	b.Write(length[:])
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	b.WriteString(kind)
+	b.Write(data)
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	b.Write(sum[:])
+}
+
+// Add the given region of the image as the next frame, shown for the
+// given number of milliseconds.
+func (a *apng) addFrame(img *image.RGBA, region image.Rectangle, delay int) error {
+	var encoded bytes.Buffer
+	if err := a.encoder.Encode(&encoded, img.SubImage(region)); err != nil {
+		return err
+	}
+	header, idat, err := pngChunks(encoded.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// Every frame must share the first's bit depth and color type.
+	if a.header == nil {
+		a.header = header
+	} else if !bytes.Equal(a.header[8:], header[8:]) {
+		return badPNG
+	}
+
+	control := make([]byte, 26)
+	binary.BigEndian.PutUint32(control[0:], a.sequence)
+	binary.BigEndian.PutUint32(control[4:], uint32(region.Dx()))
+	binary.BigEndian.PutUint32(control[8:], uint32(region.Dy()))
+	binary.BigEndian.PutUint32(control[12:], uint32(region.Min.X))
+	binary.BigEndian.PutUint32(control[16:], uint32(region.Min.Y))
+	binary.BigEndian.PutUint16(control[20:], uint16(delay))
+	binary.BigEndian.PutUint16(control[22:], 1000)
+	control[24] = 0 // APNG_DISPOSE_OP_NONE
+	control[25] = 1 // APNG_BLEND_OP_OVER
+	writeChunk(&a.body, "fcTL", control)
+	a.sequence++
+
+	if a.frames == 0 {
+		writeChunk(&a.body, "IDAT", idat)
+	} else {
+		data := make([]byte, 4+len(idat))
+		binary.BigEndian.PutUint32(data, a.sequence)
+		copy(data[4:], idat)
+		writeChunk(&a.body, "fdAT", data)
+		a.sequence++
+	}
+	a.frames++
+
+	return nil
+}
+
+// Assemble the finished animation, looping forever.
+func (a *apng) bytes() []byte {
+	var b bytes.Buffer
+	b.Write(pngSignature)
+	writeChunk(&b, "IHDR", a.header)
+
+	control := make([]byte, 8)
+	binary.BigEndian.PutUint32(control[0:], uint32(a.frames))
+	writeChunk(&b, "acTL", control)
+
+	b.Write(a.body.Bytes())
+	writeChunk(&b, "IEND", nil)
+	return b.Bytes()
+}
+
+// The pixel bounds of one step of the solution path.
+func segmentBounds(prev, pos position) image.Rectangle {
+	return image.Rect(
+		prev.x*cellWidth+border+halfCellWidth, prev.y*cellWidth+border+halfCellWidth,
+		pos.x*cellWidth+border+halfCellWidth, pos.y*cellWidth+border+halfCellWidth,
+	).Inset(-1)
+}
+
+// Render the solution being drawn as an animated PNG running for the
+// given number of milliseconds. The image is drawn separately from
+// the frame buffer, so whatever is on screen is left alone.
+func (m *maze) solutionAPNG(path []position, duration float64) ([]byte, error) {
+	defer tr(ace("rendering apng"))
+
+	width, height := m.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	m.drawOnto(img)
+
+	steps := len(path) - 1
+	frames := steps
+	if frames > apngMaxFrames {
+		frames = apngMaxFrames
+	}
+	if frames < 1 {
+		frames = 1
+	}
+	delay := int(duration) / frames
+	if delay < 1 {
+		delay = 1
+	}
+
+	a := &apng{encoder: png.Encoder{CompressionLevel: png.BestCompression}}
+	if err := a.addFrame(img, img.Bounds(), delay); err != nil {
+		return nil, err
+	}
+
+	drawn := 0
+	for frame := 1; frame <= frames && steps > 0; frame++ {
+		var region image.Rectangle
+		for target := frame * steps / frames; drawn < target; drawn++ {
+			m.drawSegment(img, path[drawn], path[drawn+1])
+			region = region.Union(segmentBounds(path[drawn], path[drawn+1]))
+		}
+		if region.Empty() {
+			continue
+		}
+		if err := a.addFrame(img, region.Intersect(img.Bounds()), delay); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.bytes(), nil
+}
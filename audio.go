@@ -0,0 +1,88 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// Sonification: after every move, we play a short tone for each way
+// the player could go next, panned and pitched by direction, followed
+// by a tone that rises in pitch as the player gets closer to the
+// finish. Bumping into a wall makes a low buzz instead.
+
+const (
+	cueLength  = 0.1  // Length of each tone (in seconds)
+	cueGap     = 0.12 // Time from the start of one tone to the next (in seconds)
+	cueVolume  = 0.2  // Peak gain of each tone
+	nearPitch  = 880  // Pitch of the distance tone at the finish (in Hz)
+	farPitch   = 220  // Pitch of the distance tone as far from the finish as it gets (in Hz)
+	bumpPitch  = 110  // Pitch of the buzz when walking into a wall (in Hz)
+	bumpLength = 0.2  // Length of the buzz (in seconds)
+)
+
+// Pitch (in Hz) and stereo position of each direction's tone.
+var cues = map[direction]struct{ pitch, pan float64 }{
+	north: {784, 0},
+	south: {392, 0},
+	east:  {523, 1},
+	west:  {523, -1},
+}
+
+// The audio context is created the first time we need it, which is
+// always in response to a key press; browsers won't start audio
+// before the user has interacted with the page.
+var audioContext js.Value = js.Undefined()
+
+func sonifying() bool {
+	return js.Global().Get("document").Call("getElementById", "sonify").Get("checked").Truthy()
+}
+
+// Play a tone of the given pitch, stereo position, and waveform,
+// starting the given number of seconds from now.
+func tone(pitch, pan, delay, length float64, wave string) {
+	if audioContext.IsUndefined() {
+		audioContext = js.Global().Get("AudioContext").New()
+	}
+
+	start := audioContext.Get("currentTime").Float() + delay
+	oscillator := audioContext.Call("createOscillator")
+	oscillator.Set("type", wave)
+	oscillator.Get("frequency").Set("value", pitch)
+
+	panner := audioContext.Call("createStereoPanner")
+	panner.Get("pan").Set("value", pan)
+
+	gain := audioContext.Call("createGain")
+	gain.Get("gain").Call("setValueAtTime", cueVolume, start)
+	gain.Get("gain").Call("exponentialRampToValueAtTime", 0.001, start+length)
+
+	oscillator.Call("connect", panner).Call("connect", gain).Call("connect", audioContext.Get("destination"))
+	oscillator.Call("start", start)
+	oscillator.Call("stop", start+length)
+}
+
+// Play the cues for the player's position: their exits and distance
+// to the finish if they moved, or a buzz if they hit a wall.
+func (g *game) sonify(moved bool) {
+	if !moved {
+		tone(bumpPitch, 0, 0, bumpLength, "square")
+		return
+	}
+
+	delay := 0.0
+	for _, d := range g.exits() {
+		tone(cues[d].pitch, cues[d].pan, delay, cueLength, "sine")
+		delay += cueGap
+	}
+
+	farthest := 1
+	for _, distance := range g.distances {
+		if distance > farthest {
+			farthest = distance
+		}
+	}
+	nearness := 1 - float64(g.distance(g.player))/float64(farthest)
+	tone(farPitch+(nearPitch-farPitch)*nearness, 0, delay+cueGap, cueLength*2, "triangle")
+}
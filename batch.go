@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"math/rand"
+	"time"
+)
+
+// Batches are for books of mazes: many mazes of the same size, from
+// different seeds. Different seeds almost always give different mazes,
+// but small mazes repeat and can come out nearly the same, so batches
+// can check each maze against the ones before it and regenerate any
+// that are too similar.
+
+const (
+	maxSeed        = 1<<53 - 1 // Largest seed JS numbers (and the seed input) hold exactly
+	batchAttempts  = 10        // Seeds tried per maze before giving up on uniqueness
+	minWallChanges = 0.1       // Fraction of inner walls that must differ between unique mazes
+	maxBatchSize   = 1000      // Most mazes in one batch
+)
+
+// A seed for a maze when none is given, from the clock. It's no bigger
+// than maxSeed, so that handed to JS and back it names the same maze.
+func randomSeed() int64 {
+	return time.Now().UnixNano()%maxSeed + 1
+}
+
+// A maze's structure as a bitset of its inner walls, two bits a cell:
+// whether it opens south, then east.
+type fingerprint []uint64
+
+func (m *maze) fingerprint() fingerprint {
+	f := make(fingerprint, (len(m.cells)*2+63)/64)
+	for i, c := range m.cells {
+		if c.openings[south] && i/m.width < m.height-1 {
+			f[i*2/64] |= 1 << (i * 2 % 64)
+		}
+		if c.openings[east] {
+			f[(i*2+1)/64] |= 1 << ((i*2 + 1) % 64)
+		}
+	}
+	return f
+}
+
+// A hash of the fingerprint, for spotting exact repeats quickly.
+func (f fingerprint) hash() uint64 {
+	h := fnv.New64a()
+	for _, w := range f {
+		var b [8]byte
+		for i := range b {
+			b[i] = byte(w >> (i * 8))
+		}
+		h.Write(b[:])
+	}
+	return h.Sum64()
+}
+
+// The number of walls that differ between two mazes of the same size.
+func (f fingerprint) difference(g fingerprint) int {
+	n := 0
+	for i := range f {
+		n += bits.OnesCount64(f[i] ^ g[i])
+	}
+	return n
+}
+
+// Pick seeds for a batch of mazes of the given size and algorithm. Seeds count up
+// from the given one, or are random if it's zero. If unique is set,
+// seeds whose mazes repeat, or nearly repeat, an earlier maze in the
+// batch are skipped; if we can't find enough unique mazes, the batch
+// comes up short.
+func batchSeeds(height, width int, oppositeStart bool, algorithm string, count int, seed int64, unique bool) []int64 {
+	defer tr(ace("choosing batch seeds"))
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	next := func() int64 {
+		if seed == 0 {
+			return rng.Int63n(maxSeed) + 1
+		}
+		s := seed
+		seed++
+		return s
+	}
+
+	var seeds []int64
+	if !unique {
+		for len(seeds) < count {
+			seeds = append(seeds, next())
+		}
+		return seeds
+	}
+
+	hashes := make(map[uint64]bool)
+	var prints []fingerprint
+	walls := (height-1)*width + (width-1)*height
+	for tries := 0; len(seeds) < count && tries < count*batchAttempts; tries++ {
+		s := next()
+		m := newMaze(height, width, rand.New(rand.NewSource(s)), oppositeStart)
+		m.generateWith(algorithm)
+
+		f := m.fingerprint()
+		h := f.hash()
+		if hashes[h] {
+			continue
+		}
+		similar := false
+		for _, p := range prints {
+			if float64(f.difference(p)) < minWallChanges*float64(walls) {
+				similar = true
+				break
+			}
+		}
+		if similar {
+			continue
+		}
+
+		hashes[h] = true
+		prints = append(prints, f)
+		seeds = append(seeds, s)
+	}
+
+	if len(seeds) < count {
+		fmt.Printf("Error: only found %d unique mazes of %d\n", len(seeds), count)
+	}
+	return seeds
+}
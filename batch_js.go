@@ -0,0 +1,137 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"syscall/js"
+)
+
+// The JS side of batch generation; see batch.go.
+
+// The page's settings, if they're good enough to generate a batch with.
+func batchArguments() (arguments, bool) {
+	settings, err := getArguments()
+	if err != nil || settings.height < 2 || settings.width < 2 || !drawnWhole(int(settings.height), int(settings.width)) {
+		fmt.Printf("Error: %s\n", err)
+		return settings, false
+	}
+	return settings, true
+}
+
+func batchSize(count int) int {
+	if count > maxBatchSize {
+		return maxBatchSize
+	}
+	return count
+}
+
+func batchSeedsJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 {
+		return js.Null()
+	}
+	settings, ok := batchArguments()
+	if !ok {
+		return js.Null()
+	}
+	unique := len(args) > 1 && args[1].Truthy()
+
+	result := js.Global().Get("Array").New()
+	for _, s := range batchSeeds(int(settings.height), int(settings.width), settings.oppositeStart, settings.algorithm, batchSize(args[0].Int()), settings.seed, unique) {
+		result.Call("push", s)
+	}
+	return result
+}
+
+// Generate a batch of mazes with the page's settings, shaped and
+// labeled the way generate does it, and archive them in the given
+// formats.
+func batchArchive(settings arguments, count int, unique bool, formats []string) ([]byte, error) {
+	seeds := batchSeeds(int(settings.height), int(settings.width), settings.oppositeStart, settings.algorithm, batchSize(count), settings.seed, unique)
+	batch := make([]batchMaze, 0, len(seeds))
+	for _, seed := range seeds {
+		m := newMaze(int(settings.height), int(settings.width), rand.New(rand.NewSource(seed)), settings.oppositeStart)
+		m.generateWith(settings.algorithm)
+		if settings.decoys > 0 {
+			m.addDecoys(settings.decoys)
+		}
+		if settings.braid > 0 {
+			m.braid(float64(settings.braid) / 100)
+		}
+		if settings.targetSolutions > 1 {
+			m.addSolutions(settings.targetSolutions)
+		}
+		batch = append(batch, batchMaze{
+			m:         m,
+			id:        mazeID(m.height, m.width, seed, settings.oppositeStart, m.algorithm),
+			label:     settings.labelFor(m, seed),
+			decoys:    settings.decoys,
+			solutions: settings.targetSolutions,
+			braid:     settings.braid,
+		})
+	}
+	return batchZip(batch, formats, printStyle(), printMarksSetting(pageSettings), pageSetupSetting(pageSettings))
+}
+
+// batchZip(count, options) returns a Blob of a ZIP archive of a batch
+// of mazes with the current settings, or null if it can't.
+func batchZipJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 {
+		return js.Null()
+	}
+	settings, ok := batchArguments()
+	if !ok {
+		return js.Null()
+	}
+
+	unique, formats := false, batchFormats
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		unique = args[1].Get("unique").Truthy()
+		if f := args[1].Get("formats"); f.Type() == js.TypeObject {
+			formats = nil
+			for i := 0; i < f.Length(); i++ {
+				formats = append(formats, f.Index(i).String())
+			}
+		}
+	}
+
+	data, err := batchArchive(settings, args[0].Int(), unique, formats)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+	return js.Global().Get("Blob").New([]interface{}{bytesToJS(data)}, map[string]interface{}{"type": "application/zip"})
+}
+
+// Export a batch of the size chosen on the page, with unique mazes, as
+// one ZIP archive.
+func exportBatchCallback() {
+	settings, ok := batchArguments()
+	if !ok {
+		return
+	}
+	count := js.Global().Get("document").Call("getElementById", "batchSize").Get("valueAsNumber").Float()
+	if !(count >= 1) {
+		return
+	}
+
+	data, err := batchArchive(settings, int(count), true, batchFormats)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("mazes.zip", "application/zip", bytesToJS(data))
+}
+
+// Build the JS-facing batch API. seeds(count, unique) picks seeds for a
+// batch of mazes using the current settings; pages can then set each
+// seed and generate or export it in turn, or use MazeGen.batchZip to
+// get the whole batch as one archive.
+func batchControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	seeds := js.FuncOf(batchSeedsJS)
+	controls.Set("seeds", seeds)
+	return controls, []js.Func{seeds}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// A batch can be downloaded as a single ZIP archive rather than as a
+// file per maze, which browsers ask about one save dialog at a time.
+// The archive holds every maze twice, in puzzles/ without its solution
+// and in keys/ with it, in each of the formats asked for. PDFs already
+// carry the solution on a layer that's hidden until it's switched on,
+// so those are only in puzzles/. Alongside them, report.csv grades
+// every maze, for choosing and ordering the puzzles in a book.
+
+var unknownBatchFormat = errors.New("batch archives hold png, svg, and pdf files")
+
+// The formats batch archives can hold, by file extension.
+var batchFormats = []string{"png", "svg", "pdf"}
+
+// A maze in a batch, with what its files need to know about it.
+type batchMaze struct {
+	m                 *maze
+	id, label         string
+	decoys, solutions int
+	braid             int
+}
+
+// The maze as a file in the given format, with the given path (if
+// any) drawn on it.
+func (b batchMaze) file(format string, path []position, style string, marks printMarks, page pageSetup) ([]byte, error) {
+	switch format {
+	case "png":
+		width, height := b.m.imageSize()
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		b.m.drawOnto(img)
+		b.m.drawStyledPath(img, path, style)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return addPNGText(buf.Bytes(), mazeParameters(b.m, b.id, b.decoys, b.solutions, b.braid))
+	case "svg":
+		return []byte(b.m.svgPage(path, style, page)), nil
+	case "pdf":
+		return b.m.pdf(path, b.label, marks, page), nil
+	}
+	return nil, unknownBatchFormat
+}
+
+// Build a ZIP archive of the batch in the given formats. Keys draw the
+// solution in the given print style, and vector files are laid out on
+// the given page.
+func batchZip(mazes []batchMaze, formats []string, style string, marks printMarks, page pageSetup) ([]byte, error) {
+	defer tr(ace("building batch archive"))
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	add := func(name string, data []byte) error {
+		w, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	var report bytes.Buffer
+	report.WriteString("name,solution,difficulty,dead ends,junctions,longest branch\n")
+	for i, b := range mazes {
+		solution := b.m.solution()
+		name := fmt.Sprintf("%03d-%s", i+1, b.id)
+		var a analysis
+		b.m.grade(&a)
+		fmt.Fprintf(&report, "%s,%d,%d,%d,%d,%d\n", name, a.solution, a.difficulty, a.deadEnds, a.junctions, a.longestBranch)
+		for _, format := range formats {
+			var path []position
+			if format == "pdf" {
+				path = solution // Hidden until its layer is switched on
+			}
+			puzzle, err := b.file(format, path, style, marks, page)
+			if err != nil {
+				return nil, err
+			}
+			if err := add("puzzles/"+name+"."+format, puzzle); err != nil {
+				return nil, err
+			}
+			if format == "pdf" {
+				continue
+			}
+
+			key, err := b.file(format, solution, style, marks, page)
+			if err != nil {
+				return nil, err
+			}
+			if err := add("keys/"+name+"-key."+format, key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := add("report.csv", report.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
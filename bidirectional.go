@@ -0,0 +1,95 @@
+package main
+
+// The depth-first solver keeps a map of every cell it has visited,
+// which for the largest mazes is a lot of memory and a lot of hashing.
+// Searching breadth first from both ends at once, and stopping where
+// the searches meet, visits far fewer cells: each search only has to
+// reach about halfway. We track visits in flat slices indexed like
+// m.cells, rather than maps.
+
+const bidirectionalCells = 100 * 100 // Mazes with at least this many cells are solved bidirectionally
+
+// Solve the maze, choosing the solver by its size and shape. Both
+// breadth-first solvers find the shortest way through; the depth-first
+// one finds the only way through a perfect maze, but given loops it
+// could find a longer one.
+func (m *maze) solution() []position {
+	switch {
+	case len(m.cells) >= bidirectionalCells:
+		return m.solveBidirectional()
+	case m.loops:
+		return m.findPath(m.start, m.finish)
+	}
+	return m.solve()
+}
+
+// Solve via breadth-first search from the start and the finish at
+// once, expanding whichever frontier is smaller, until they meet.
+func (m *maze) solveBidirectional() []position {
+	defer tr(ace("solving maze bidirectionally"))
+
+	// parent[i] is the cell we reached cell i from, plus one, so that
+	// zero means unvisited; each search's first cell is its own parent.
+	fromStart := make([]int32, len(m.cells))
+	fromFinish := make([]int32, len(m.cells))
+	index := func(p position) int { return p.y*m.width + p.x }
+	cellAt := func(i int) position { return position{x: i % m.width, y: i / m.width} }
+
+	fromStart[index(m.start)] = int32(index(m.start)) + 1
+	fromFinish[index(m.finish)] = int32(index(m.finish)) + 1
+	startQueue, finishQueue := []int{index(m.start)}, []int{index(m.finish)}
+
+	// Expand one whole level of a search; returns the cell where it
+	// met the other, or -1.
+	expand := func(queue []int, parent, other []int32) ([]int, int) {
+		var next []int
+		for _, i := range queue {
+			if other[i] != 0 {
+				return nil, i
+			}
+			pos := cellAt(i)
+			for _, dir := range []direction{north, south, east, west} {
+				np, err := dir.translate(pos, m)
+				if err != nil || !m.cells[i].openings[dir] || parent[index(np)] != 0 {
+					continue
+				}
+				parent[index(np)] = int32(i) + 1
+				if other[index(np)] != 0 {
+					return nil, index(np)
+				}
+				next = append(next, index(np))
+			}
+		}
+		return next, -1
+	}
+
+	meet := -1
+	for meet < 0 && len(startQueue) > 0 && len(finishQueue) > 0 {
+		if len(startQueue) <= len(finishQueue) {
+			startQueue, meet = expand(startQueue, fromStart, fromFinish)
+		} else {
+			finishQueue, meet = expand(finishQueue, fromFinish, fromStart)
+		}
+	}
+	if meet < 0 {
+		panic("maze has no solution")
+	}
+
+	// Walk back to the start, turn around, then walk on to the finish.
+	var path []position
+	for i := meet; ; i = int(fromStart[i]) - 1 {
+		path = append(path, cellAt(i))
+		if i == index(m.start) {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for i := meet; i != index(m.finish); {
+		i = int(fromFinish[i]) - 1
+		path = append(path, cellAt(i))
+	}
+
+	return path
+}
@@ -0,0 +1,58 @@
+package main
+
+// A braided maze has loops instead of some of its dead ends, so there's
+// more than one way around it: better for games, where being chased
+// into a dead end is no fun. We braid by going through the dead ends
+// in a random order and knocking down one wall of each, preferring a
+// wall onto another dead end, which gets rid of both at once. Like the
+// rest of generation this uses the maze's RNG, so the same seed and
+// settings always give the same maze.
+
+// Knock down a wall in the given fraction (from 0 to 1) of the maze's
+// dead ends. Returns how many walls were knocked down.
+func (m *maze) braid(fraction float64) int {
+	defer tr(ace("braiding maze"))
+
+	var deadEnds []position
+	for i := range m.cells {
+		if p := (position{x: i % m.width, y: i / m.width}); m.branches(p) == 1 {
+			deadEnds = append(deadEnds, p)
+		}
+	}
+	m.rng.Shuffle(len(deadEnds), func(i, j int) { deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i] })
+
+	if fraction > 1 {
+		fraction = 1
+	}
+	knocked := 0
+	for _, p := range deadEnds[:int(fraction*float64(len(deadEnds))+0.5)] {
+		// Knocking down an earlier dead end's wall may have opened
+		// this one up already.
+		if m.branches(p) != 1 {
+			continue
+		}
+
+		var walls, ontoDeadEnds []direction
+		for _, d := range permutations[m.rng.Intn(len(permutations))] {
+			if !m.solidWall(p, d) {
+				continue
+			}
+			walls = append(walls, d)
+			if np, _ := d.translate(p, m); m.branches(np) == 1 {
+				ontoDeadEnds = append(ontoDeadEnds, d)
+			}
+		}
+		if len(ontoDeadEnds) > 0 {
+			walls = ontoDeadEnds
+		}
+		if len(walls) > 0 {
+			m.carve(p, walls[0])
+			knocked++
+		}
+	}
+
+	if knocked > 0 {
+		m.loops = true
+	}
+	return knocked
+}
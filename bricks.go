@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A brick plan builds the thick wall grid (see dots) out of toy bricks
+// on a base plate, one stud per square. Each layer lays its bricks
+// along the walls, in runs as long as it can, with the next layer
+// crossing the corners and staggering the joints so the walls hold
+// together. The plan is an SVG, to print: the base plate size, a parts
+// list, and a diagram of every layer.
+
+const (
+	brickLayers = 3  // Layers of bricks in a wall
+	studSize    = 8  // Width of a stud in the plan (in mm, as on real bricks)
+	planMargin  = 16 // Margin around everything in the plan (in mm)
+	planText    = 8  // Height of a line of text in the plan (in mm)
+)
+
+// Brick lengths we build with, longest first, and their colors in the
+// plan.
+var (
+	brickLengths = []int{4, 3, 2, 1}
+	brickColors  = map[int]string{4: "#d01012", 3: "#0055bf", 2: "#f2cd37", 1: "#237841"}
+)
+
+// A 1xN brick, in studs, lying along a row or down a column.
+type brick struct {
+	x, y, length int
+	vertical     bool
+}
+
+// Split a run of the given length into bricks, longest first, without
+// leaving a single stud at the end if that can be helped. Staggered
+// runs start with a short brick, so joints don't line up with the
+// layer below.
+func splitRun(length int, staggered bool) []int {
+	var lengths []int
+	if staggered && length > brickLengths[0] {
+		lengths = append(lengths, 2)
+		length -= 2
+	}
+	for length > 0 {
+		for _, l := range brickLengths {
+			if l <= length && (length-l != 1 || l <= 2) {
+				lengths = append(lengths, l)
+				length -= l
+				break
+			}
+		}
+	}
+	return lengths
+}
+
+// Lay one layer of bricks on the grid: runs in the given direction
+// first, then across, then single studs wherever's left.
+func layBricks(grid [][]bool, vertical, staggered bool) []brick {
+	covered := make([][]bool, len(grid))
+	for y := range grid {
+		covered[y] = make([]bool, len(grid[y]))
+	}
+	free := func(x, y int) bool { return grid[y][x] && !covered[y][x] }
+
+	var bricks []brick
+	lay := func(vertical bool, minimum int) {
+		outer, inner := len(grid), len(grid[0])
+		if vertical {
+			outer, inner = inner, outer
+		}
+		at := func(i, j int) (int, int) {
+			if vertical {
+				return i, j
+			}
+			return j, i
+		}
+		for i := 0; i < outer; i++ {
+			for j := 0; j < inner; {
+				if x, y := at(i, j); !free(x, y) {
+					j++
+					continue
+				}
+				run := 0
+				for k := j; k < inner; k++ {
+					if x, y := at(i, k); !free(x, y) {
+						break
+					}
+					run++
+				}
+				if run < minimum {
+					j += run
+					continue
+				}
+				for _, l := range splitRun(run, staggered) {
+					x, y := at(i, j)
+					bricks = append(bricks, brick{x: x, y: y, length: l, vertical: vertical})
+					for k := 0; k < l; k++ {
+						cx, cy := at(i, j+k)
+						covered[cy][cx] = true
+					}
+					j += l
+				}
+			}
+		}
+	}
+	lay(vertical, 2)
+	lay(!vertical, 2)
+	lay(vertical, 1)
+	return bricks
+}
+
+// Plan every layer of the maze's walls.
+func (m *maze) brickPlan() [][]brick {
+	grid := m.dots(2)
+	var layers [][]brick
+	for i := 0; i < brickLayers; i++ {
+		layers = append(layers, layBricks(grid, i%2 == 1, i%2 == 1))
+	}
+	return layers
+}
+
+// Render the maze's brick plan as an SVG document.
+func (m *maze) bricksSVG() string {
+	defer tr(ace("rendering brick plan"))
+
+	layers := m.brickPlan()
+	grid := m.dots(2)
+	columns, rows := len(grid[0]), len(grid)
+	plateWidth, plateHeight := columns*studSize, rows*studSize
+
+	counts := map[int]int{}
+	for _, layer := range layers {
+		for _, b := range layer {
+			counts[b.length]++
+		}
+	}
+	var lengths []int
+	for l := range counts {
+		lengths = append(lengths, l)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+
+	header := (3 + len(lengths)) * planText
+	width := plateWidth + planMargin*2
+	height := planMargin + header + len(layers)*(plateHeight+planText+planMargin)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%dmm" height="%dmm" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`+"\n", width, height, width, height, planText*3/4)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+
+	y := planMargin + planText
+	fmt.Fprintf(&b, `<text x="%d" y="%d">Base plate: %d × %d studs, %d layers</text>`+"\n", planMargin, y, columns, rows, len(layers))
+	y += planText * 2
+	for _, l := range lengths {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="black" stroke-width="0.5"/>`, planMargin, y-planText/2-studSize/4, l*studSize/2, studSize/2, brickColors[l])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">1×%d brick: %d</text>`+"\n", planMargin+brickLengths[0]*studSize/2+planText, y, l, counts[l])
+		y += planText
+	}
+
+	y += planMargin - planText
+	for i, layer := range layers {
+		fmt.Fprintf(&b, `<text x="%d" y="%d">Layer %d</text>`+"\n", planMargin, y+planText*3/4, i+1)
+		y += planText
+		fmt.Fprintf(&b, `<g transform="translate(%d %d)">`+"\n", planMargin, y)
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#e0e0e0"/>`+"\n", plateWidth, plateHeight)
+		for _, br := range layer {
+			w, h := br.length*studSize, studSize
+			if br.vertical {
+				w, h = h, w
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="black" stroke-width="0.5"/>`+"\n", br.x*studSize, br.y*studSize, w, h, brickColors[br.length])
+			for k := 0; k < br.length; k++ {
+				sx, sy := br.x, br.y+k
+				if !br.vertical {
+					sx, sy = br.x+k, br.y
+				}
+				fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="%g" fill="none" stroke="black" stroke-width="0.3"/>`, sx*studSize+studSize/2, sy*studSize+studSize/2, studSize*0.3)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("</g>\n")
+		y += plateHeight + planMargin
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
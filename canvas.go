@@ -0,0 +1,101 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"syscall/js"
+)
+
+// The canvas renderer draws the maze by issuing path commands to the
+// canvas's 2D context instead of exporting pixels. The canvas backing
+// store is sized for the screen's device pixel ratio, so walls stay
+// crisp on high-DPI screens, and CSS sizes the canvas in CSS pixels.
+
+const canvasRenderer = "canvas" // Renderer argument selecting this renderer
+
+// Render the maze to the canvas at the given zoom (1 being the size
+// the raster renderer draws at), animating the solution if asked.
+func renderCanvas(m *maze, path []position, label string, zoom float64, animated bool, duration float64) {
+	defer tr(ace("issuing canvas commands"))
+
+	canvas := targetCanvas
+	ctx := canvas.Call("getContext", "2d")
+	ratio := js.Global().Get("devicePixelRatio").Float()
+	if ratio <= 0 {
+		ratio = 1
+	}
+	scale := ratio * zoom
+
+	width, height := m.imageSize()
+	canvas.Set("width", math.Round(float64(width)*scale))
+	canvas.Set("height", math.Round(float64(height)*scale))
+	canvas.Get("style").Set("width", strconv.FormatFloat(float64(width)*zoom, 'f', 2, 64)+"px")
+	canvas.Get("style").Set("height", strconv.FormatFloat(float64(height)*zoom, 'f', 2, 64)+"px")
+
+	// We've resized the canvas behind putMaze's back, so make sure it
+	// starts over next time the raster renderer is used.
+	js.Global().Set("lastHeight", js.Undefined())
+
+	// Offset lines an odd number of pixels thick by half a pixel, so
+	// that they land on pixels rather than straddling two.
+	offset := float64(lineWidth%2) / 2
+	ctx.Call("setTransform", scale, 0, 0, scale, scale*offset, scale*offset)
+	ctx.Set("lineCap", "square")
+	ctx.Set("lineWidth", lineWidth)
+
+	// Draw the solution between the given steps.
+	drawPath := func(first, last int) {
+		ctx.Call("beginPath")
+		for i := first; i <= last; i++ {
+			x, y := path[i].x*cellWidth+border+halfCellWidth, path[i].y*cellWidth+border+halfCellWidth
+			if i == first {
+				ctx.Call("moveTo", x, y)
+			} else {
+				ctx.Call("lineTo", x, y)
+			}
+		}
+		ctx.Set("strokeStyle", cssColor(currentTheme.solution))
+		ctx.Call("stroke")
+	}
+
+	drawWalls := func() {
+		ctx.Set("fillStyle", cssColor(currentTheme.background))
+		ctx.Call("fillRect", -1, -1, width+1, height+1)
+
+		ctx.Call("beginPath")
+		for _, s := range m.walls() {
+			ctx.Call("moveTo", s.x0*cellWidth+border, s.y0*cellWidth+border)
+			ctx.Call("lineTo", s.x1*cellWidth+border, s.y1*cellWidth+border)
+		}
+		ctx.Set("strokeStyle", cssColor(currentTheme.wall))
+		ctx.Call("stroke")
+
+		drawLabel(ctx, label, width, height, currentLabelStyle)
+	}
+
+	drawWalls()
+	js.Global().Call("enableExports")
+
+	if len(path) < 2 {
+		return
+	}
+	if animated {
+		animate(newAnimation(len(path)-1, duration,
+			drawWalls,
+			func(i int) { drawPath(i, i+1) },
+			func() {},
+		))
+		return
+	}
+	drawPath(0, len(path)-1)
+}
+
+// Undo renderCanvas's CSS sizing, for the raster renderer.
+func resetCanvasStyle() {
+	style := targetCanvas.Get("style")
+	style.Set("width", "")
+	style.Set("height", "")
+}
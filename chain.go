@@ -0,0 +1,52 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+	"math/rand"
+)
+
+// A chain is a campaign of mazes, one after another: each maze's
+// entrance is in the same column as the previous maze's exit, so that
+// stacked one above the next they flow from one level to the next.
+// The whole chain comes from one seed.
+
+const maxStages = 20 // Most mazes in a chain
+
+// Generate a chain of the given number of mazes with the given
+// algorithm.
+func newChain(height, width int, seed int64, oppositeStart bool, algorithm string, stages int) []*maze {
+	defer tr(ace("generating chain"))
+
+	if stages > maxStages {
+		stages = maxStages
+	}
+	rng := rand.New(rand.NewSource(seed))
+	var chain []*maze
+	for i := 0; i < stages; i++ {
+		m := newMaze(height, width, rng, oppositeStart)
+		if i > 0 {
+			m.start.x = chain[i-1].finish.x
+		}
+		m.generateWith(algorithm)
+		chain = append(chain, m)
+	}
+	return chain
+}
+
+// Draw a chain, top to bottom, with the solutions if asked.
+func chainImage(chain []*maze, solved bool) *image.RGBA {
+	defer tr(ace("drawing chain"))
+
+	width, height := chain[0].imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height*len(chain)))
+	stage := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, m := range chain {
+		m.drawOnto(stage)
+		if solved {
+			m.drawPath(stage, m.solution())
+		}
+		draw.Draw(img, stage.Bounds().Add(image.Pt(0, height*i)), stage, image.Point{0, 0}, draw.Src)
+	}
+	return img
+}
@@ -0,0 +1,32 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+)
+
+// Export a chain of mazes, with the current settings, as one PNG.
+func exportChainCallback() {
+	args, err := getArguments()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	if args.stages < 1 || !drawnWhole(int(args.height), int(args.width)) {
+		return
+	}
+
+	seed := args.seed
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	chain := newChain(int(args.height), int(args.width), seed, args.oppositeStart, args.algorithm, args.stages)
+	data, err := encodePNG(chainImage(chain, args.solution))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke(fmt.Sprintf("chain-%x.png", seed), "image/png", bytesToJS(data))
+}
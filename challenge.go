@@ -0,0 +1,55 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// In a challenge, the player gets only so many moves: the fewest the
+// maze can be solved in, plus a few to spare. The moves left are shown
+// in the border under the maze, and running out ends the game.
+
+const failedEvent = "failed" // Game event: the player ran out of moves
+
+// Give the player the given number of moves more than the fewest it
+// takes to finish.
+func (g *game) limitMoves(spare int) {
+	g.limit = g.distance(g.m.start) + spare
+}
+
+// The number of moves the player has left, if there's a limit.
+func (g *game) movesLeft() int {
+	return g.limit - g.moves
+}
+
+// Check whether the player has just run out of moves.
+func (g *game) outOfMoves() bool {
+	if g.limit <= 0 || g.failed || g.movesLeft() > 0 || g.finished() {
+		return false
+	}
+	g.failed = true
+	g.end()
+	g.emit(failedEvent)
+	g.report(false)
+	return true
+}
+
+// Write the moves left in the border under the maze image of the
+// given size, on the given canvas context.
+func (g *game) drawMovesLeft(ctx js.Value, width, height int) {
+	if g.limit <= 0 {
+		return
+	}
+	ctx.Call("save")
+	ctx.Set("textAlign", "left")
+	ctx.Set("fillStyle", "black")
+	if g.failed {
+		ctx.Set("fillStyle", "red")
+	}
+	ctx.Set("font", labelFontSize+" "+labelFont)
+	ctx.Call("fillText", fmt.Sprintf("%d moves left", g.movesLeft()), border, height-border+halfCellWidth*2)
+	ctx.Call("restore")
+}
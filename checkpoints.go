@@ -0,0 +1,141 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"syscall/js"
+	"time"
+)
+
+// Checkpoints are flags spaced along the solution. Reaching one makes
+// it where the player starts over if an enemy catches them. Reached
+// flags are drawn gray, and are part of the game's saved state.
+
+const (
+	maxCheckpoints  = 10           // Most checkpoints in a maze
+	checkpointEvent = "checkpoint" // Game event: the player reached a checkpoint
+)
+
+var badGameState = errors.New("saved game is for another maze")
+
+// Flag colors.
+var (
+	flagColor    = image.NewUniform(color.RGBA{0, 160, 0, 255})
+	reachedColor = image.NewUniform(color.RGBA{160, 160, 160, 255})
+)
+
+// Space the given number of checkpoints evenly along the solution,
+// between the start and the finish.
+func (g *game) addCheckpoints(n int) {
+	if n > maxCheckpoints {
+		n = maxCheckpoints
+	}
+	path := g.m.solution()
+	for i := 1; i <= n && len(path) > n+1; i++ {
+		g.checkpoints = append(g.checkpoints, path[i*(len(path)-1)/(n+1)])
+	}
+	g.reached = make([]bool, len(g.checkpoints))
+}
+
+// Check whether the player has reached a checkpoint.
+func (g *game) checkpoint() bool {
+	for i, p := range g.checkpoints {
+		if p == g.player && g.respawnAt != p {
+			g.reached[i] = true
+			g.respawnAt = p
+			g.emit(checkpointEvent)
+			return true
+		}
+	}
+	return false
+}
+
+// Draw the checkpoints' flags: a pole on the left of the cell, with
+// the flag flying from its top.
+func (g *game) drawCheckpoints(img draw.Image) {
+	for i, p := range g.checkpoints {
+		col := flagColor
+		if g.reached[i] {
+			col = reachedColor
+		}
+		x := p.x*cellWidth + border + cellWidth/4
+		y := p.y*cellWidth + border + cellWidth/4
+		vLine(img, x, y, y+halfCellWidth, image.Black)
+		draw.Draw(img, image.Rect(x+1, y, x+halfCellWidth, y+halfCellWidth/2), col, image.Point{0, 0}, draw.Over)
+	}
+}
+
+// Draw everything on the board: portals and checkpoints, then
+// enemies, then the player on top.
+func (g *game) drawPieces(img draw.Image) {
+	g.drawPortals(img)
+	g.drawCheckpoints(img)
+	g.drawEnemies(img)
+	g.drawPlayer(img)
+}
+
+// What saved games name their maze by: its ID, or its code if it has
+// none.
+func (g *game) mazeKey() string {
+	if currentID != "" {
+		return currentID
+	}
+	return g.m.encode(currentSeed)
+}
+
+// The game's state, as a JS object that can be stored as JSON and
+// handed back to restore.
+func (g *game) save() js.Value {
+	state := js.Global().Get("Object").New()
+	state.Set("id", g.mazeKey())
+	state.Set("player", positionToJS(g.player))
+	state.Set("respawn", positionToJS(g.respawnAt))
+	state.Set("moves", g.moves)
+	state.Set("hints", g.hints)
+	state.Set("elapsed", float64(g.elapsed().Milliseconds()))
+	reached := js.Global().Get("Array").New()
+	for i, ok := range g.reached {
+		if ok {
+			reached.Call("push", i)
+		}
+	}
+	state.Set("checkpoints", reached)
+	return state
+}
+
+// Pick up a saved game where it left off. It must be for the current
+// maze.
+func (g *game) restore(state js.Value) error {
+	if state.Type() != js.TypeObject || state.Get("id").String() != g.mazeKey() {
+		return badGameState
+	}
+	player, err := positionFromJS(state.Get("player"))
+	if err != nil {
+		return err
+	}
+	respawn, err := positionFromJS(state.Get("respawn"))
+	if err != nil {
+		return err
+	}
+
+	g.player, g.respawnAt = player, respawn
+	g.moves = state.Get("moves").Int()
+	g.hints = state.Get("hints").Int()
+	g.failed = g.limit > 0 && g.movesLeft() <= 0 && !g.finished()
+	g.started = time.Now().Add(-time.Duration(state.Get("elapsed").Float()) * time.Millisecond)
+	g.reached = make([]bool, len(g.checkpoints))
+	reached := state.Get("checkpoints")
+	for i := 0; i < reached.Length(); i++ {
+		if k := reached.Index(i).Int(); k >= 0 && k < len(g.reached) {
+			g.reached[k] = true
+		}
+	}
+	g.redraw()
+	g.follow()
+	return nil
+}
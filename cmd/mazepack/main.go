@@ -0,0 +1,75 @@
+// Command mazepack bundles the maze generator into a single HTML file,
+// with the WASM module, wasm_exec.js, and the page's scripts inlined,
+// so it can be shipped as one file and used offline.
+//
+// Build the generator first (see the Makefile), then run
+//
+//	go run ./cmd/mazepack -o mazes.html
+//
+// from the top of the repository.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// The script tags index.html loads its scripts with.
+var scriptTag = regexp.MustCompile(`<script src="([^"]+)"></script>`)
+
+// The script loading our glue code; the module goes just before it.
+const glueScript = "index.js"
+
+func main() {
+	dir := flag.String("dir", ".", "directory holding index.html and its scripts")
+	module := flag.String("module", "twistylittlepassages.gz", "gzipped WASM module, relative to -dir")
+	output := flag.String("o", "mazes.html", "file to write")
+	flag.Parse()
+
+	if err := pack(*dir, *module, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func pack(dir, module, output string) error {
+	page, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		return err
+	}
+	wasm, err := os.ReadFile(filepath.Join(dir, module))
+	if err != nil {
+		return err
+	}
+
+	// Inline every script, so nothing needs fetching. index.js knows to
+	// take the module from mazeModule instead of fetching it.
+	var failed error
+	page = scriptTag.ReplaceAllFunc(page, func(tag []byte) []byte {
+		name := string(scriptTag.FindSubmatch(tag)[1])
+		script, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			failed = err
+			return tag
+		}
+
+		var b bytes.Buffer
+		if name == glueScript {
+			fmt.Fprintf(&b, "<script>var mazeModule = \"%s\";</script>\n", base64.StdEncoding.EncodeToString(wasm))
+		}
+		b.WriteString("<script>\n")
+		b.Write(bytes.ReplaceAll(script, []byte("</script"), []byte(`<\/script`)))
+		b.WriteString("\n</script>")
+		return b.Bytes()
+	})
+	if failed != nil {
+		return failed
+	}
+
+	return os.WriteFile(output, page, 0644)
+}
@@ -0,0 +1,122 @@
+// Command mazetypes writes TypeScript definitions for the generator's
+// MazeGen JS API, from the apiTypes and apiMethods tables in api.go.
+// It's run by go generate:
+//
+//	go generate
+//
+// It reads the tables' source rather than importing them, since the
+// API itself only builds for WASM.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	noTable  = errors.New("api table not found")
+	badEntry = errors.New("api table entries must be composite literals of string fields")
+)
+
+// The string fields of each entry of the named table.
+func table(file *ast.File, name string) ([]map[string]string, error) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if len(value.Names) != 1 || value.Names[0].Name != name || len(value.Values) != 1 {
+				continue
+			}
+			list, ok := value.Values[0].(*ast.CompositeLit)
+			if !ok {
+				return nil, badEntry
+			}
+
+			var entries []map[string]string
+			for _, elt := range list.Elts {
+				lit, ok := elt.(*ast.CompositeLit)
+				if !ok {
+					return nil, badEntry
+				}
+				entry := make(map[string]string)
+				for _, field := range lit.Elts {
+					kv, ok := field.(*ast.KeyValueExpr)
+					if !ok {
+						return nil, badEntry
+					}
+					if s, ok := kv.Value.(*ast.BasicLit); ok && s.Kind == token.STRING {
+						entry[kv.Key.(*ast.Ident).Name], _ = strconv.Unquote(s.Value)
+					}
+				}
+				entries = append(entries, entry)
+			}
+			return entries, nil
+		}
+	}
+	return nil, noTable
+}
+
+func generate(source string) ([]byte, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	types, err := table(file, "apiTypes")
+	if err != nil {
+		return nil, err
+	}
+	methods, err := table(file, "apiMethods")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := func(b *bytes.Buffer, indent, text string) {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(text, "*/", "* /"))
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by mazetypes from %s. DO NOT EDIT.\n\n", source)
+	for _, t := range types {
+		doc(&b, "", t["doc"])
+		fmt.Fprintf(&b, "type %s = %s;\n\n", t["name"], t["definition"])
+	}
+	b.WriteString("interface MazeGenAPI {\n")
+	for i, m := range methods {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		doc(&b, "    ", m["doc"])
+		fmt.Fprintf(&b, "    %s%s;\n", m["name"], m["signature"])
+	}
+	b.WriteString("}\n\ndeclare const MazeGen: MazeGenAPI;\n")
+	return b.Bytes(), nil
+}
+
+func main() {
+	output := flag.String("o", "mazegen.d.ts", "file to write")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: mazetypes [-o file] api.go\n")
+		os.Exit(2)
+	}
+
+	defs, err := generate(flag.Arg(0))
+	if err == nil {
+		err = os.WriteFile(*output, defs, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
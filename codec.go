@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+)
+
+// A maze ID names a maze by how to generate it again, which only works
+// for mazes exactly as generated. A maze code carries the maze itself:
+// its size, start and finish, the seed and algorithm it came from, and
+// its walls, so mazes that decoys, braiding, or extra solutions have
+// changed, or that were shaped to a mask, come back just as they were.
+// Codes are URL-safe base64 without padding, to go in a link.
+//
+// Inside, a code is a version byte, then a byte of flags, a byte each
+// of height and width, a byte each of the start's and finish's x and
+// y, a byte naming the algorithm (its place in generators), and the
+// seed in eight bytes, big-endian. Mazes too big for a byte to give
+// their height or width have wide codes, of their own version, which
+// give those six numbers in two bytes each, big-endian. Then come two bits for each cell,
+// left to right and top to bottom, four cells to a byte starting from
+// the low bits: whether it opens east, then whether it opens south.
+// West and north openings are the neighbors' east and south ones,
+// except the entrance, which is always north of the start, and the
+// exit, always south of the finish. Mazes shaped to a mask end with a
+// bit for each cell, eight to a byte, saying whether it's in the mask.
+
+// Versions written in codes; others are refused.
+const (
+	codeVersion     = 1
+	codeWideVersion = 2
+)
+
+// Flags in a code.
+const (
+	codeLoops    = 1 << iota // The maze has loops
+	codeMasked               // A mask follows the cells
+	codeOpposite             // The start and finish are in opposite corners
+	codeDecoys               // Decoys were grown in the maze
+)
+
+// Bytes before the cells.
+const (
+	codeHeader     = 17
+	codeWideHeader = 23
+)
+
+var badMazeCode = errors.New("malformed maze code")
+
+// The code for the maze, generated from the given seed.
+func (m *maze) encode(seed int64) string {
+	n := len(m.cells)
+	wide := m.height > 255 || m.width > 255
+	data := make([]byte, 0, codeWideHeader+(n+3)/4+(n+7)/8)
+
+	var flags byte
+	if m.loops {
+		flags |= codeLoops
+	}
+	if m.mask != nil {
+		flags |= codeMasked
+	}
+	if m.oppositeStart {
+		flags |= codeOpposite
+	}
+	if m.decoys {
+		flags |= codeDecoys
+	}
+	if wide {
+		data = append(data, codeWideVersion, flags)
+	} else {
+		data = append(data, codeVersion, flags)
+	}
+	for _, v := range []int{m.height, m.width, m.start.x, m.start.y, m.finish.x, m.finish.y} {
+		if wide {
+			data = append(data, byte(v>>8))
+		}
+		data = append(data, byte(v))
+	}
+	algorithm := 0
+	for i, g := range generators {
+		if g.name == m.algorithm {
+			algorithm = i
+		}
+	}
+	data = append(data, byte(algorithm), 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(data[len(data)-8:], uint64(seed))
+
+	cells := make([]byte, (n+3)/4)
+	for i, c := range m.cells {
+		if c.openings[east] && i%m.width < m.width-1 {
+			cells[i/4] |= 1 << (i % 4 * 2)
+		}
+		if c.openings[south] && i/m.width < m.height-1 {
+			cells[i/4] |= 2 << (i % 4 * 2)
+		}
+	}
+	data = append(data, cells...)
+
+	if m.mask != nil {
+		mask := make([]byte, (n+7)/8)
+		for i, in := range m.mask {
+			if in {
+				mask[i/8] |= 1 << (i % 8)
+			}
+		}
+		data = append(data, mask...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Rebuild the maze a code carries, and read the seed it was generated
+// from.
+func decodeMaze(code string) (m *maze, seed int64, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(code)
+	header := codeHeader
+	if err == nil && len(data) > 0 && data[0] == codeWideVersion {
+		header = codeWideHeader
+	}
+	if err != nil || len(data) < header || (data[0] != codeVersion && data[0] != codeWideVersion) {
+		return nil, 0, badMazeCode
+	}
+
+	// Height, width, and the start's and finish's x and y.
+	var v [6]int
+	for i := range v {
+		if header == codeWideHeader {
+			v[i] = int(binary.BigEndian.Uint16(data[2+i*2:]))
+		} else {
+			v[i] = int(data[2+i])
+		}
+	}
+	flags, algorithm := data[1], int(data[header-9])
+	height, width := v[0], v[1]
+	if height < 2 || width < 2 || height > maxDimension || width > maxDimension || algorithm >= len(generators) {
+		return nil, 0, badMazeCode
+	}
+	n := height * width
+	size := header + (n+3)/4
+	if flags&codeMasked != 0 {
+		size += (n + 7) / 8
+	}
+	if len(data) != size {
+		return nil, 0, badMazeCode
+	}
+
+	seed = int64(binary.BigEndian.Uint64(data[header-8:]))
+	m = newMaze(height, width, rand.New(rand.NewSource(seed)), flags&codeOpposite != 0)
+	m.start = position{v[2], v[3]}
+	m.finish = position{v[4], v[5]}
+	m.algorithm = generators[algorithm].name
+	m.loops = flags&codeLoops != 0
+	m.decoys = flags&codeDecoys != 0
+	for _, p := range []position{m.start, m.finish} {
+		if p.x >= width || p.y >= height {
+			return nil, 0, badMazeCode
+		}
+	}
+
+	// The mask comes first, so that passages out of it are refused.
+	cells := data[header:]
+	if flags&codeMasked != 0 {
+		mask := cells[(n+3)/4:]
+		m.mask = make([]bool, n)
+		for i := range m.mask {
+			m.mask[i] = mask[i/8]&(1<<(i%8)) != 0
+		}
+		if !m.inMask(m.start) || !m.inMask(m.finish) {
+			return nil, 0, badMazeCode
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		bits := cells[i/4] >> (i % 4 * 2)
+		p := position{x: i % width, y: i / width}
+		for _, open := range []struct {
+			bit byte
+			dir direction
+		}{{1, east}, {2, south}} {
+			if bits&open.bit == 0 {
+				continue
+			}
+			if _, err := open.dir.translate(p, m); err != nil || !m.inMask(p) {
+				return nil, 0, badMazeCode
+			}
+			m.carve(p, open.dir)
+		}
+	}
+
+	// Codes can be typed in or mangled, and mazes without a way through
+	// can't be solved.
+	if m.findPath(m.start, m.finish) == nil {
+		return nil, 0, badMazeCode
+	}
+
+	m.openEnds()
+	return m, seed, nil
+}
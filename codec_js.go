@@ -0,0 +1,46 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+func encodeJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil {
+		return js.Null()
+	}
+	return currentMaze.encode(currentSeed)
+}
+
+// Draw the maze a code carries, with the settings from the given
+// source, and make it the current maze.
+func decodeJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return js.Null()
+	}
+	s := pageSettings
+	if len(args) > 1 {
+		s = settingSource{args[1]}
+	}
+
+	m, seed, err := decodeMaze(args[0].String())
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+	useTheme(themeFrom(s))
+	settings, err := argumentsFrom(s)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+
+	currentWorld = nil
+	renderMaze(m, seed, settings)
+	currentID = m.id(seed)
+	currentShaping.decoys, currentShaping.solutions, currentShaping.braid = 0, 0, 0
+	return mazeResult(m)
+}
@@ -0,0 +1,139 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/rand"
+	"strconv"
+	"syscall/js"
+)
+
+// Comparison mode generates a maze of the same size from the same
+// seed with every algorithm we know, and shows them side by side in a
+// grid with some statistics about each, to show how the algorithms'
+// mazes differ in character.
+
+// Statistics shown for each maze in a comparison.
+func (m *maze) stats() string {
+	path := m.solution()
+	deadEnds := 0
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if m.branches(position{x: x, y: y}) == 1 {
+				deadEnds++
+			}
+		}
+	}
+	return fmt.Sprintf("solution %d, difficulty %d, dead ends %d", len(path), m.difficulty(path), deadEnds)
+}
+
+func compareCallback() {
+	defer tr(ace("comparing algorithms"))
+
+	args, err := getArguments()
+	if err != nil || args.height < 2 || args.width < 2 || !drawnWhole(int(args.height), int(args.width)) {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+
+	seed := args.seed
+	if seed == 0 {
+		seed = randomSeed()
+	}
+
+	stopAnimation()
+	stopGame()
+	showGLCanvas(false)
+	resetCanvasStyle()
+
+	// The comparison isn't a maze we can export or play.
+	currentMaze, currentSolution = nil, nil
+
+	columns := int(math.Ceil(math.Sqrt(float64(len(generators)))))
+	rows := (len(generators) + columns - 1) / columns
+
+	mazes := make([]*maze, len(generators))
+	for i, g := range generators {
+		mazes[i] = newMaze(int(args.height), int(args.width), rand.New(rand.NewSource(seed)), args.oppositeStart)
+		g.generate(mazes[i])
+	}
+
+	width, height := mazes[0].imageSize()
+	grid := image.NewRGBA(image.Rect(0, 0, width*columns, height*rows))
+	tile := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, m := range mazes {
+		m.drawOnto(tile)
+		if args.solution {
+			m.drawPath(tile, m.solution())
+		}
+		origin := image.Point{X: i % columns * width, Y: i / columns * height}
+		draw.Draw(grid, tile.Bounds().Add(origin), tile, image.Point{0, 0}, draw.Src)
+	}
+	dither(grid, args.ditherMethod)
+
+	frameBuffer = grid
+	export("")
+
+	ctx := targetCanvas.Call("getContext", "2d")
+	ctx.Set("fillStyle", "black")
+	ctx.Set("font", labelFontSize+" "+labelFont)
+	ctx.Set("textAlign", "left")
+	for i, m := range mazes {
+		x := float64(i%columns*width + border)
+		y := float64(i/columns*height + border)
+		ctx.Call("fillText", generators[i].name, x, y-14)
+		ctx.Call("fillText", m.stats(), x, y-3)
+	}
+}
+
+// Diff mode generates two mazes of the current size, the second from
+// its own seed and algorithm, and shows where their passages differ.
+func diffCallback() {
+	defer tr(ace("diffing mazes"))
+
+	args, err := getArguments()
+	if err != nil || args.height < 2 || args.width < 2 || !drawnWhole(int(args.height), int(args.width)) {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+
+	seed := args.seed
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	document := js.Global().Get("document")
+	other, err := strconv.ParseInt(document.Call("getElementById", "diffSeed").Get("value").String(), 10, 64)
+	if err != nil || other == 0 {
+		other = seed
+	}
+	algorithm := document.Call("getElementById", "diffAlgorithm").Get("value").String()
+
+	stopAnimation()
+	stopGame()
+	showGLCanvas(false)
+	resetCanvasStyle()
+
+	// The diff isn't a maze we can export or play.
+	currentMaze, currentSolution = nil, nil
+
+	a := newMaze(int(args.height), int(args.width), rand.New(rand.NewSource(seed)), args.oppositeStart)
+	a.generateWith(args.algorithm)
+	b := newMaze(int(args.height), int(args.width), rand.New(rand.NewSource(other)), args.oppositeStart)
+	b.generateWith(algorithm)
+
+	img, d := drawDiff(a, b)
+	frameBuffer = img
+	export("")
+
+	ctx := targetCanvas.Call("getContext", "2d")
+	ctx.Set("fillStyle", "black")
+	ctx.Set("font", labelFontSize+" "+labelFont)
+	ctx.Set("textAlign", "left")
+	ctx.Call("fillText", fmt.Sprintf("A: seed %x, %s; B: seed %x, %s", seed, a.algorithm, other, algorithm), border, border-14)
+	ctx.Call("fillText", fmt.Sprintf("shared %d, only in A %d (blue), only in B %d (orange)", d.shared, d.onlyA, d.onlyB), border, border-3)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A cutting kit is the maze as flat parts for a laser cutter: a base
+// plate, and a strip for every wall segment (see walls), standing on
+// edge with tabs along its bottom that fit slots in the plate. Every
+// strip is numbered, and the plate is engraved with the number of the
+// strip that goes in each set of slots.
+//
+// Cuts are made kerf wide, so parts are grown by half the kerf all
+// round and slots shrunk by half of it, and the tabs and slots are
+// sized for the material's thickness, so the kit fits together as cut.
+// Horizontal strips run the full length of their wall and vertical
+// ones fit between them, so that corners meet.
+
+var badCutting = errors.New("cells must be over twice the material thick, and the kerf less than it")
+
+// The dimensions of a cutting kit (in mm).
+type cutting struct {
+	kerf      float64 // Width of material the cut removes
+	thickness float64 // Thickness of the material
+	cell      float64 // Width of a cell
+	height    float64 // Height of the walls, above the plate
+}
+
+const (
+	cutGap       = 2.0 // Space between parts on the sheet (in mm)
+	cutLine      = `fill="none" stroke="red" stroke-width="0.1"`
+	engraveStyle = `fill="blue" font-family="sans-serif"`
+)
+
+// Where a strip's tabs are, as offsets from its start.
+func (c cutting) tabs(s segment) [][2]float64 {
+	length, offset := s.x1-s.x0, c.thickness/2
+	if s.x0 == s.x1 {
+		length, offset = s.y1-s.y0, -c.thickness/2
+	}
+	width := c.cell / 3
+	var tabs [][2]float64
+	for j := 0; j < length; j++ {
+		center := (float64(j)+0.5)*c.cell + offset
+		tabs = append(tabs, [2]float64{center - width/2, center + width/2})
+	}
+	return tabs
+}
+
+// How long a strip is.
+func (c cutting) stripLength(s segment) float64 {
+	if s.x0 == s.x1 {
+		return float64(s.y1-s.y0)*c.cell - c.thickness
+	}
+	return float64(s.x1-s.x0)*c.cell + c.thickness
+}
+
+// The outline of a strip with its top left at the given point, grown
+// by half the kerf.
+func (c cutting) stripPath(s segment, x, y float64) string {
+	k := c.kerf / 2
+	length := c.stripLength(s)
+	bottom := y + c.height + k
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "M%.3f %.3fH%.3fV%.3f", x-k, y-k, x+length+k, bottom)
+	tabs := c.tabs(s)
+	for i := len(tabs) - 1; i >= 0; i-- {
+		a, z := x+tabs[i][0]-k, x+tabs[i][1]+k
+		fmt.Fprintf(&b, "H%.3fV%.3fH%.3fV%.3f", z, bottom+c.thickness, a, bottom)
+	}
+	fmt.Fprintf(&b, "H%.3fZ", x-k)
+	return b.String()
+}
+
+// Render the maze as a cutting kit, in an SVG document.
+func (m *maze) cuttingSVG(c cutting) string {
+	defer tr(ace("rendering cutting kit"))
+
+	walls := m.walls()
+	margin := c.cell
+	plateWidth := float64(m.width)*c.cell + margin*2
+	plateHeight := float64(m.height)*c.cell + margin*2
+	text := c.cell / 3
+
+	var parts strings.Builder
+
+	// The plate, with a slot under every tab.
+	fmt.Fprintf(&parts, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" %s/>`+"\n",
+		-c.kerf/2, -c.kerf/2, plateWidth+c.kerf, plateHeight+c.kerf, cutLine)
+	k := c.kerf / 2
+	for i, s := range walls {
+		x0, y0 := margin+float64(s.x0)*c.cell, margin+float64(s.y0)*c.cell
+		vertical := s.x0 == s.x1
+		for _, tab := range c.tabs(s) {
+			if vertical {
+				fmt.Fprintf(&parts, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" %s/>`+"\n",
+					x0-c.thickness/2+k, y0+c.thickness/2+tab[0]+k, c.thickness-c.kerf, tab[1]-tab[0]-c.kerf, cutLine)
+			} else {
+				fmt.Fprintf(&parts, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" %s/>`+"\n",
+					x0-c.thickness/2+tab[0]+k, y0-c.thickness/2+k, tab[1]-tab[0]-c.kerf, c.thickness-c.kerf, cutLine)
+			}
+		}
+		lx, ly := x0+c.cell/2, y0+c.thickness/2+text
+		if vertical {
+			lx, ly = x0+c.thickness/2+text/4, y0+c.cell/2
+		}
+		fmt.Fprintf(&parts, `<text x="%.3f" y="%.3f" font-size="%.3f" %s>%d</text>`+"\n", lx, ly, text, engraveStyle, i+1)
+	}
+
+	// The strips, in rows below the plate.
+	x, y := 0.0, plateHeight+cutGap*2
+	rowHeight := c.height + c.thickness + c.kerf + cutGap
+	for i, s := range walls {
+		length := c.stripLength(s)
+		if x > 0 && x+length > plateWidth {
+			x, y = 0, y+rowHeight
+		}
+		fmt.Fprintf(&parts, `<path d="%s" %s/>`+"\n", c.stripPath(s, x, y), cutLine)
+		fmt.Fprintf(&parts, `<text x="%.3f" y="%.3f" font-size="%.3f" %s>%d</text>`+"\n", x+text/2, y+text*1.5, text, engraveStyle, i+1)
+		x += length + c.kerf + cutGap
+	}
+	sheetHeight := y + rowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.3fmm" height="%.3fmm" viewBox="%.3f %.3f %.3f %.3f">`+"\n",
+		plateWidth+cutGap*2, sheetHeight+cutGap*2, -cutGap, -cutGap, plateWidth+cutGap*2, sheetHeight+cutGap*2)
+	b.WriteString(parts.String())
+	b.WriteString("</svg>\n")
+	return b.String()
+}
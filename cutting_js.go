@@ -0,0 +1,35 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// Read the kit's dimensions from the page.
+func cuttingSettings() cutting {
+	value := func(id string) float64 {
+		return js.Global().Get("document").Call("getElementById", id).Get("valueAsNumber").Float()
+	}
+	return cutting{
+		kerf:      value("cutKerf"),
+		thickness: value("cutThickness"),
+		cell:      value("cutCell"),
+		height:    value("cutHeight"),
+	}
+}
+
+// Export the current maze as a laser cutting kit.
+func exportCuttingCallback() {
+	if !exportable() {
+		return
+	}
+	c := cuttingSettings()
+	if c.cell <= c.thickness*2 || c.height <= 0 || c.kerf < 0 || c.kerf >= c.thickness {
+		fmt.Printf("Error: %s\n", badCutting)
+		return
+	}
+	offerDownload.Invoke("maze-kit.svg", "image/svg+xml", currentMaze.cuttingSVG(c))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+// Dead-end filling solves a maze without exploring it at all: fill in
+// every dead end, which may turn the cell before it into a dead end
+// too, and keep going until there are none left. Only the start, the
+// finish, and the passages between them are left unfilled. Animated,
+// the dead ends retreat round by round until the solution stands out.
+
+var filledColor = image.NewUniform(color.RGBA{200, 200, 200, 255})
+
+// Fill in the dead ends, returning the cells filled in each round, and
+// the solution through what's left.
+func (m *maze) fillDeadEnds() ([][]position, []position) {
+	defer tr(ace("filling dead ends"))
+
+	open := make([]int, len(m.cells))
+	for i := range m.cells {
+		open[i] = m.branches(position{x: i % m.width, y: i / m.width})
+	}
+
+	filled := make([]bool, len(m.cells))
+	deadEnd := func(p position) bool {
+		return p != m.start && p != m.finish && m.inMask(p) && !filled[p.y*m.width+p.x] && open[p.y*m.width+p.x] <= 1
+	}
+
+	var round []position
+	for i := range m.cells {
+		if p := (position{x: i % m.width, y: i / m.width}); deadEnd(p) {
+			round = append(round, p)
+		}
+	}
+
+	var rounds [][]position
+	for len(round) > 0 {
+		for _, p := range round {
+			filled[p.y*m.width+p.x] = true
+		}
+
+		var next []position
+		for _, p := range round {
+			for _, dir := range []direction{north, south, east, west} {
+				np, err := dir.translate(p, m)
+				if err != nil || !m.at(p).openings[dir] || filled[np.y*m.width+np.x] {
+					continue
+				}
+				open[np.y*m.width+np.x]--
+				if deadEnd(np) {
+					next = append(next, np)
+				}
+			}
+		}
+		rounds = append(rounds, round)
+		round = next
+	}
+
+	// In a perfect maze only the solution is left; if there are loops,
+	// they're left too, so take the shortest way through.
+	return rounds, m.shortestAvoiding(m.start, m.finish, filled, nil)
+}
@@ -0,0 +1,29 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image/draw"
+)
+
+// Build an animation filling in the maze's dead ends, one round a
+// step, over the given duration, and drawing the solution at the end.
+func (m *maze) deadEndAnimation(duration float64, render func()) *animation {
+	rounds, path := m.fillDeadEnds()
+
+	var img draw.Image
+	return newAnimation(len(rounds)+1, duration,
+		func() { img = m.draw() },
+		func(i int) {
+			if i == len(rounds) {
+				m.drawPath(img, path)
+				return
+			}
+			for _, p := range rounds[i] {
+				m.fillCell(img, p, filledColor)
+			}
+		},
+		render,
+	)
+}
@@ -0,0 +1,93 @@
+package main
+
+// In a maze carved at random, dead ends are mostly short, and a big
+// maze is hard mostly because it's big. Decoys make it hard on
+// purpose: we keep the solution, throw away everything else, and
+// grow a few long, winding false paths off the solution before
+// filling in the rest of the maze around them.
+
+const maxDecoys = 10 // Most decoys we'll grow
+
+// Carve a winding path into unvisited cells from the given cell, for
+// up to the given number of steps or until it's boxed in.
+func (m *maze) walk(from position, visited []bool, steps int) {
+	p := from
+	for i := 0; i < steps; i++ {
+		found := false
+		for _, dir := range permutations[m.rng.Intn(len(permutations))] {
+			np, err := dir.translate(p, m)
+			if err == nil && !visited[np.y*m.width+np.x] {
+				m.carve(p, dir)
+				visited[np.y*m.width+np.x] = true
+				p = np
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+}
+
+// Carve every unvisited cell reachable from the given one into the
+// maze, the same way generate does.
+func (m *maze) grow(from position, visited []bool) {
+	stack := stack{[]position{from}}
+	for !stack.empty() {
+		found := false
+		p := stack.peek()
+		for _, dir := range permutations[m.rng.Intn(len(permutations))] {
+			np, err := dir.translate(p, m)
+			if err == nil && !visited[np.y*m.width+np.x] {
+				m.carve(p, dir)
+				visited[np.y*m.width+np.x] = true
+				stack.push(np)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			stack.pop()
+		}
+	}
+}
+
+// Rebuild the maze around its solution with the given number of long
+// decoys branching off it, spaced evenly along it. Each is grown to be
+// half as long as the solution, if there's room.
+func (m *maze) addDecoys(n int) {
+	defer tr(ace("growing decoys"))
+
+	if n > maxDecoys {
+		n = maxDecoys
+	}
+	path := m.solution()
+
+	m.decoys = true
+	m.cells = make([]cell, len(m.cells))
+	visited := make([]bool, len(m.cells))
+	visited[m.start.y*m.width+m.start.x] = true
+	for i := 1; i < len(path); i++ {
+		for _, dir := range []direction{north, south, east, west} {
+			if np, err := dir.translate(path[i-1], m); err == nil && np == path[i] {
+				m.carve(path[i-1], dir)
+			}
+		}
+		visited[path[i].y*m.width+path[i].x] = true
+	}
+
+	for i := 1; i <= n; i++ {
+		m.walk(path[i*len(path)/(n+1)], visited, len(path)/2)
+	}
+
+	for i := range m.cells {
+		if visited[i] {
+			m.grow(position{x: i % m.width, y: i / m.width}, visited)
+		}
+	}
+
+	m.at(m.start).openings[north] = true
+	m.at(m.finish).openings[south] = true
+}
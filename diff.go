@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+// Diff mode lays two mazes of the same size over each other to show
+// where their passages differ: how much a different seed or algorithm
+// changes a maze's structure. Passages are drawn between cell centers,
+// in gray where both mazes have them and in each maze's own color
+// where only one does; walls are only drawn where both mazes have them.
+
+var (
+	sharedColor = image.NewUniform(color.RGBA{200, 200, 200, 255})
+	onlyAColor  = image.NewUniform(color.RGBA{0, 90, 255, 255})
+	onlyBColor  = image.NewUniform(color.RGBA{255, 120, 0, 255})
+)
+
+// How the passages of two mazes compare.
+type passageDiff struct {
+	shared, onlyA, onlyB int
+}
+
+// Compare the passages between cells of two mazes of the same size,
+// calling fn (if it's given) for each passage either has.
+func diffPassages(a, b *maze, fn func(p, np position, inA, inB bool)) passageDiff {
+	var d passageDiff
+	for y := 0; y < a.height; y++ {
+		for x := 0; x < a.width; x++ {
+			p := position{x: x, y: y}
+			for _, dir := range []direction{south, east} {
+				np, err := dir.translate(p, a)
+				if err != nil {
+					continue
+				}
+				inA, inB := a.at(p).openings[dir], b.at(p).openings[dir]
+				switch {
+				case inA && inB:
+					d.shared++
+				case inA:
+					d.onlyA++
+				case inB:
+					d.onlyB++
+				default:
+					continue
+				}
+				if fn != nil {
+					fn(p, np, inA, inB)
+				}
+			}
+		}
+	}
+	return d
+}
+
+// Draw the diff of two mazes of the same size.
+func drawDiff(a, b *maze) (*image.RGBA, passageDiff) {
+	defer tr(ace("drawing diff"))
+
+	width, height := a.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, 0, height, 0, width, image.White)
+
+	// The walls both mazes share, drawn as drawCell draws them.
+	for y := 0; y < a.height; y++ {
+		for x := 0; x < a.width; x++ {
+			p := position{x: x, y: y}
+			var both cell
+			for d := range both.openings {
+				both.openings[d] = a.at(p).openings[d] || b.at(p).openings[d]
+			}
+			a.drawCell(img, x, y, &both)
+		}
+	}
+
+	d := diffPassages(a, b, func(p, np position, inA, inB bool) {
+		col := sharedColor
+		switch {
+		case !inB:
+			col = onlyAColor
+		case !inA:
+			col = onlyBColor
+		}
+		a.drawLine(img, p, np, col)
+	})
+	return img, d
+}
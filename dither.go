@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Dithering methods. Anything else means no dithering.
+const (
+	orderedDither   = "ordered"
+	diffusionDither = "diffusion"
+)
+
+// The 4x4 Bayer matrix used for ordered dithering, scaled to 0-255.
+var bayer = [4][4]int{
+	{0, 128, 32, 160},
+	{192, 64, 224, 96},
+	{48, 176, 16, 144},
+	{240, 112, 208, 80},
+}
+
+// Perceived brightness of a pixel, 0-255, using the Rec. 601 weights.
+func luminance(pix []uint8) int {
+	return (int(pix[0])*299 + int(pix[1])*587 + int(pix[2])*114) / 1000
+}
+
+func setGray(pix []uint8, black bool) {
+	v := uint8(255)
+	if black {
+		v = 0
+	}
+	pix[0], pix[1], pix[2], pix[3] = v, v, v, 255
+}
+
+// Reduce the image, in place, to pure black and white using the given
+// dithering method. Colors print on a laser printer as flat, muddy
+// grays; dithering turns them into clean patterns of toner dots
+// instead, so that a red solution or a colored fill stays distinct
+// from the black walls.
+//
+// Only RGBA images are dithered; paletted frame buffers are left as
+// they are.
+func dither(dst draw.Image, method string) {
+	img, ok := dst.(*image.RGBA)
+	if !ok {
+		return
+	}
+
+	switch method {
+	case orderedDither:
+		defer tr(ace("ordered dithering"))
+		orderedDitherImage(img)
+	case diffusionDither:
+		defer tr(ace("error diffusion dithering"))
+		diffuseImage(img)
+	}
+}
+
+func orderedDitherImage(img *image.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			pix := img.Pix[img.PixOffset(x, y):]
+			setGray(pix, luminance(pix) <= bayer[y%4][x%4])
+		}
+	}
+}
+
+// Floyd-Steinberg error diffusion. We only ever need the error for
+// the current row and the next one, so we keep two rows and swap.
+func diffuseImage(img *image.RGBA) {
+	b := img.Bounds()
+	width := b.Dx()
+	current := make([]int, width+2)
+	next := make([]int, width+2)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := 0; x < width; x++ {
+			pix := img.Pix[img.PixOffset(b.Min.X+x, y):]
+			v := luminance(pix) + current[x+1]
+			black := v < 128
+			setGray(pix, black)
+
+			e := v
+			if !black {
+				e = v - 255
+			}
+			current[x+2] += e * 7 / 16
+			next[x] += e * 3 / 16
+			next[x+1] += e * 5 / 16
+			next[x+2] += e * 1 / 16
+		}
+
+		current, next = next, current
+		for i := range next {
+			next[i] = 0
+		}
+	}
+}
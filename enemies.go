@@ -0,0 +1,185 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"syscall/js"
+)
+
+// Enemies walk the corridors of playable mode, a cell at a time, and
+// send the player back to the start (or their last checkpoint) if they
+// meet. A patrolling enemy walks back and forth along a fixed route; a
+// wandering one picks a random way at every junction and only turns
+// back at dead ends.
+// Routes and starting places come from the maze's seed, so the same
+// maze always has the same enemies.
+
+const (
+	patrolEnemies   = "patrol" // Enemy argument for fixed routes
+	wanderEnemies   = "wander" // Enemy argument for random walks
+	maxEnemies      = 20       // Most enemies in a maze
+	enemyStepTime   = 400      // Time between enemy steps (in milliseconds)
+	enemyClearance  = 3        // Steps from the start enemies must keep away, initially
+	enemyPlacements = 20       // Tries at placing each enemy
+	caughtEvent     = "caught" // Game event: an enemy caught the player
+)
+
+// An enemy's marker.
+var orange = image.NewUniform(color.RGBA{255, 140, 0, 255})
+
+type enemy struct {
+	pos, prev position
+	route     []position // Cells patrolled, or nil to wander
+	at, dir   int        // Where on the route it is, and which way it's going
+}
+
+// Place the given number of enemies, of the given kind, in the game's
+// maze, away from the start.
+func (g *game) addEnemies(n int, kind string, seed int64) {
+	if n > maxEnemies {
+		n = maxEnemies
+	}
+	rng := rand.New(rand.NewSource(seed))
+	fromStart := g.m.distances(g.m.start)
+	far := func(p position) bool { return fromStart[p.y*g.m.width+p.x] > enemyClearance }
+	random := func() position { return position{x: rng.Intn(g.m.width), y: rng.Intn(g.m.height)} }
+
+	for i := 0; i < n; i++ {
+		for try := 0; try < enemyPlacements; try++ {
+			from := random()
+			if !far(from) {
+				continue
+			}
+			e := &enemy{pos: from, prev: from, dir: 1}
+			if kind == patrolEnemies {
+				e.route = g.m.findPath(from, random())
+				if len(e.route) < 2 {
+					continue
+				}
+			}
+			g.enemies = append(g.enemies, e)
+			break
+		}
+	}
+	g.rng = rng
+}
+
+// Take a step.
+func (e *enemy) step(g *game) {
+	e.prev = e.pos
+	if e.route != nil {
+		if e.at+e.dir < 0 || e.at+e.dir >= len(e.route) {
+			e.dir = -e.dir
+		}
+		e.at += e.dir
+		e.pos = e.route[e.at]
+		return
+	}
+
+	var ways []position
+	for _, d := range []direction{north, south, east, west} {
+		if np, err := d.translate(e.pos, g.m); err == nil && g.m.at(e.pos).openings[d] && np != e.prev {
+			ways = append(ways, np)
+		}
+	}
+	if len(ways) == 0 {
+		e.pos = e.prev
+		return
+	}
+	e.pos = ways[g.rng.Intn(len(ways))]
+}
+
+// Whether an enemy is where the player is, or has just swapped places
+// with them.
+func (g *game) caught(from position) bool {
+	for _, e := range g.enemies {
+		if e.pos == g.player || (e.pos == from && e.prev == g.player) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send the player back to the start, or their last checkpoint.
+func (g *game) respawn() {
+	g.player = g.respawnAt
+	g.emit(caughtEvent)
+	where := "the start"
+	if g.respawnAt != g.m.start {
+		where = "the checkpoint"
+	}
+	announce("Caught! Back to " + where + ". " + g.describeExits())
+}
+
+func (g *game) drawEnemies(img draw.Image) {
+	for _, e := range g.enemies {
+		x := e.pos.x*cellWidth + border + cellWidth/4
+		y := e.pos.y*cellWidth + border + cellWidth/4
+		draw.Draw(img, image.Rect(x+1, y+1, x+halfCellWidth, y+halfCellWidth), orange, image.Point{0, 0}, draw.Over)
+	}
+}
+
+// Start moving the enemies, once every frame, until stopped.
+func (g *game) startEnemies() {
+	if len(g.enemies) == 0 || !g.request.IsUndefined() {
+		return
+	}
+	if g.frame.IsUndefined() {
+		g.frame = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			g.request = js.Undefined()
+			g.tick(args[0].Float())
+			g.request = js.Global().Call("requestAnimationFrame", g.frame)
+			return nil
+		})
+	}
+	g.last = 0
+	g.request = js.Global().Call("requestAnimationFrame", g.frame)
+}
+
+func (g *game) stopEnemies() {
+	if !g.request.IsUndefined() {
+		js.Global().Call("cancelAnimationFrame", g.request)
+		g.request = js.Undefined()
+	}
+	if !g.frame.IsUndefined() {
+		g.frame.Release()
+		g.frame = js.Func{Value: js.Undefined()}
+	}
+}
+
+// Move the enemies however many steps are due by the given timestamp,
+// and redraw the cells they left and entered.
+func (g *game) tick(now float64) {
+	if g.last == 0 {
+		g.last = now
+	}
+	var cells []position
+	for ; now-g.last >= enemyStepTime; g.last += enemyStepTime {
+		for _, e := range g.enemies {
+			cells = append(cells, e.pos)
+			e.step(g)
+			cells = append(cells, e.pos)
+		}
+		if g.caught(g.player) {
+			cells = append(cells, g.player)
+			g.respawn()
+			cells = append(cells, g.player)
+		}
+	}
+	if cells != nil {
+		g.redrawCells(cells)
+	}
+}
+
+// Stop the current game, if any.
+func stopGame() {
+	if currentGame != nil {
+		currentGame.stopEnemies()
+	}
+	currentGame = nil
+}
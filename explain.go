@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+)
+
+// Explainer mode replays the recursive backtracker a step at a time,
+// for teaching depth-first search: each step either carves from the
+// cell on top of the stack into an unvisited neighbor and pushes it,
+// or, with no unvisited neighbors left, pops the stack and backtracks.
+// The cells on the stack are shaded, the current cell is highlighted,
+// and every step is described in words. Mazes are deterministic, so
+// the replay generates the maze again from its seed and records the
+// steps as it goes.
+
+// Kinds of generation step.
+const (
+	carveStep     = "carve"     // Carved a passage into a neighbor and pushed it
+	backtrackStep = "backtrack" // Popped a cell with no unvisited neighbors
+	doneStep      = "done"      // Opened the entrance and exit
+)
+
+var (
+	stackColor     = image.NewUniform(color.RGBA{255, 240, 160, 255})
+	currentColor   = image.NewUniform(color.RGBA{120, 220, 120, 255})
+	carvedColor    = image.NewUniform(color.RGBA{0, 130, 0, 255})
+	backtrackColor = image.NewUniform(color.RGBA{255, 170, 120, 255})
+)
+
+// A step taken by the recursive backtracker.
+type generationStep struct {
+	kind  string
+	at    position  // The cell on top of the stack
+	to    position  // The cell carved into, or backtracked to
+	dir   direction // The direction carved in
+	depth int       // The stack's depth after the step
+}
+
+// An explainer replaying the generation of a maze.
+type explainer struct {
+	steps   []generationStep
+	work    *maze // The maze as far as the replay has got
+	stack   []position
+	drawn   int // Steps replayed
+	visited int // Cells visited so far
+}
+
+// Record the generation of the maze of the given size from the given
+// seed, ready to replay.
+func newExplainer(height, width int, seed int64, oppositeStart bool) *explainer {
+	e := &explainer{work: newMaze(height, width, rand.New(rand.NewSource(seed)), oppositeStart)}
+	e.work.backtrack(func(s generationStep) {
+		e.steps = append(e.steps, s)
+	})
+	e.steps = append(e.steps, generationStep{kind: doneStep, at: e.work.start, to: e.work.finish})
+	e.reset()
+	return e
+}
+
+// Go back to before the first step.
+func (e *explainer) reset() {
+	e.work.cells = make([]cell, len(e.work.cells))
+	e.stack = []position{e.work.start}
+	e.drawn, e.visited = 0, 1
+}
+
+// Replay the next step.
+func (e *explainer) step() generationStep {
+	s := e.steps[e.drawn]
+	switch s.kind {
+	case carveStep:
+		e.work.carve(s.at, s.dir)
+		e.stack = append(e.stack, s.to)
+		e.visited++
+	case backtrackStep:
+		e.stack = e.stack[:len(e.stack)-1]
+	case doneStep:
+		e.work.at(e.work.start).openings[north] = true
+		e.work.at(e.work.finish).openings[south] = true
+	}
+	e.drawn++
+	return s
+}
+
+// A cell's coordinates, for descriptions.
+func cellName(p position) string {
+	return fmt.Sprintf("(%d, %d)", p.x, p.y)
+}
+
+// Describe a step in words.
+func (e *explainer) describe(i int) string {
+	s := e.steps[i]
+	prefix := fmt.Sprintf("Step %d of %d: ", i+1, len(e.steps))
+	switch s.kind {
+	case carveStep:
+		return prefix + fmt.Sprintf("carve %v from %s to unvisited %s and push it; stack depth %d", s.dir, cellName(s.at), cellName(s.to), s.depth)
+	case backtrackStep:
+		if s.depth == 0 {
+			return prefix + fmt.Sprintf("%s has no unvisited neighbors; pop it, emptying the stack, so every cell is visited", cellName(s.at))
+		}
+		return prefix + fmt.Sprintf("%s has no unvisited neighbors; pop it and backtrack to %s; stack depth %d", cellName(s.at), cellName(s.to), s.depth)
+	}
+	return prefix + fmt.Sprintf("open the entrance at %s and the exit at %s", cellName(s.at), cellName(s.to))
+}
+
+// Draw the replay so far: the maze carved so far, the stack shaded,
+// and the last step's cells highlighted.
+func (e *explainer) draw(img draw.Image) {
+	e.work.drawOnto(img)
+	for _, p := range e.stack {
+		e.work.fillCell(img, p, stackColor)
+	}
+	if e.drawn == 0 {
+		e.work.fillCell(img, e.work.start, currentColor)
+		return
+	}
+
+	s := e.steps[e.drawn-1]
+	switch s.kind {
+	case carveStep:
+		e.work.fillCell(img, s.to, currentColor)
+		e.work.drawLine(img, s.at, s.to, carvedColor)
+	case backtrackStep:
+		e.work.fillCell(img, s.at, backtrackColor)
+		if s.depth > 0 {
+			e.work.fillCell(img, s.to, currentColor)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"syscall/js"
+)
+
+// If set, a JS function called with every step the explainer replays,
+// so pages can keep a log beside the maze. Seeking backwards replays
+// the steps from the beginning, so they're reported again; each step
+// carries its index, so pages can trim their log to it.
+var explainHook js.Value = js.Undefined()
+
+func generationStepToJS(e *explainer, i int) js.Value {
+	s := e.steps[i]
+	v := js.Global().Get("Object").New()
+	v.Set("index", i)
+	v.Set("steps", len(e.steps))
+	v.Set("type", s.kind)
+	v.Set("text", e.describe(i))
+	v.Set("at", positionToJS(s.at))
+	v.Set("to", positionToJS(s.to))
+	v.Set("depth", s.depth)
+	if s.kind == carveStep {
+		v.Set("direction", s.dir.String())
+	}
+	return v
+}
+
+// Explain mode generates a maze of the current size with the recursive
+// backtracker a step at a time, as an animation that can be paused,
+// stepped, and sought through like any other.
+func explainCallback() {
+	defer tr(ace("explaining generation"))
+
+	args, err := getArguments()
+	if err != nil || args.height < 2 || args.width < 2 || !drawnWhole(int(args.height), int(args.width)) {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+
+	seed := args.seed
+	if seed == 0 {
+		seed = randomSeed()
+	}
+
+	stopAnimation()
+	stopGame()
+	showGLCanvas(false)
+	resetCanvasStyle()
+
+	m := newMaze(int(args.height), int(args.width), rand.New(rand.NewSource(seed)), args.oppositeStart)
+	m.generate()
+	currentMaze, currentSolution, currentLabel, currentSeed = m, nil, "", seed
+
+	e := newExplainer(int(args.height), int(args.width), seed, args.oppositeStart)
+	width, height := m.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	frameBuffer = img
+
+	animate(newAnimation(len(e.steps), args.solutionDuration,
+		e.reset,
+		func(i int) {
+			e.step()
+			if explainHook.Type() == js.TypeFunction {
+				explainHook.Invoke(generationStepToJS(e, i))
+			}
+		},
+		func() {
+			e.draw(img)
+			export("")
+
+			text := "Start at " + cellName(m.start) + " and push it; stack depth 1"
+			if e.drawn > 0 {
+				text = e.describe(e.drawn - 1)
+			}
+			ctx := targetCanvas.Call("getContext", "2d")
+			ctx.Set("fillStyle", "black")
+			ctx.Set("font", labelFontSize+" "+labelFont)
+			ctx.Set("textAlign", "left")
+			ctx.Call("fillText", fmt.Sprintf("seed %x, %d of %d cells visited", seed, e.visited, len(m.cells)), border, border-14)
+			ctx.Call("fillText", text, border, border-3)
+		},
+	))
+}
+
+// onExplain(fn) calls fn with every step explain mode replays; null
+// stops it.
+func onExplainJS(this js.Value, args []js.Value) interface{} {
+	explainHook = js.Undefined()
+	if len(args) > 0 && args[0].Type() == js.TypeFunction {
+		explainHook = args[0]
+	}
+	return nil
+}
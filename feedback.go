@@ -0,0 +1,61 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// When a game ends, by the player reaching the finish or by the
+// solution being shown, we hand the page everything about the session
+// so that it can ask for a rating, or tune its difficulty presets from
+// how long players actually take.
+
+// If set, a JS function called with the session data when a game ends.
+var solvedHook js.Value = js.Undefined()
+
+// The way to go from the player's position, towards the finish.
+func (g *game) nextStep() (direction, bool) {
+	for _, d := range g.exits() {
+		if np, err := d.translate(g.player, g.m); err == nil && g.distance(np) < g.distance(g.player) {
+			return d, true
+		}
+	}
+	return north, false
+}
+
+// Tell the player which way to go, and count it against them.
+func (g *game) hint() (direction, bool) {
+	d, ok := g.nextStep()
+	if !ok {
+		return d, false
+	}
+	g.hints++
+	announce(fmt.Sprintf("Hint: go %s.", d))
+	return d, true
+}
+
+// Tell the solved hook how the game went, once per game. Revealed
+// means the solution was shown rather than the player finishing; a
+// game can also end with the player out of moves (see challenge.go).
+func (g *game) report(revealed bool) {
+	if g.reported || solvedHook.Type() != js.TypeFunction {
+		return
+	}
+	g.reported = true
+	solvedHook.Invoke(map[string]interface{}{
+		"solved":     !revealed && g.finished(),
+		"revealed":   revealed,
+		"failed":     g.failed,
+		"time":       float64(g.elapsed().Milliseconds()),
+		"moves":      g.moves,
+		"hints":      g.hints,
+		"difficulty": g.m.difficulty(g.m.solution()),
+		"id":         currentIDJS(),
+		"height":     g.m.height,
+		"width":      g.m.width,
+		"seed":       fmt.Sprint(currentSeed),
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// The flood fill visualization pours paint in at the start and lets
+// it spread one cell further every step, coloring each wave by its
+// distance from the start, from blue near the start to red far away.
+// It shows at a glance which cells are reachable and how far away
+// they are.
+
+// The color of cells the given fraction of the way to the farthest.
+func waveColor(t float64) color.RGBA {
+	return color.RGBA{R: uint8(255 * t), G: 96, B: uint8(255 * (1 - t)), A: 255}
+}
+
+// Fill a cell's floor, and the gaps in the walls to any neighbors it
+// opens onto, so that connected cells run together.
+func (m *maze) fillCell(img draw.Image, p position, col image.Image) {
+	before, after := lineReach()
+	x0, y0 := p.x*cellWidth+border+after+1, p.y*cellWidth+border+after+1
+	x1, y1 := p.x*cellWidth+border+cellWidth-before, p.y*cellWidth+border+cellWidth-before
+	eastOpen := p.x < m.width-1 && m.at(p).openings[east]
+	southOpen := p.y < m.height-1 && m.at(p).openings[south]
+	if eastOpen {
+		x1 += lineWidth
+	}
+	if southOpen {
+		y1 += lineWidth
+	}
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), col, image.Point{0, 0}, draw.Over)
+
+	// Put back the corner if a neighbor's wall runs through it.
+	if eastOpen && southOpen {
+		if !m.at(position{p.x + 1, p.y}).openings[south] || !m.at(position{p.x, p.y + 1}).openings[east] {
+			draw.Draw(img, image.Rect(x1-lineWidth, y1-lineWidth, x1, y1), wallColor, image.Point{0, 0}, draw.Src)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Build an animation flooding the maze from the start, one wave of
+// cells a step, over the given duration. When the flood is done the
+// given path, if any, is drawn on top.
+func (m *maze) flood(path []position, duration float64, render func()) *animation {
+	distances := m.distances(m.start)
+	farthest := 0
+	for _, d := range distances {
+		if d > farthest {
+			farthest = d
+		}
+	}
+
+	waves := make([][]position, farthest+1)
+	for i, d := range distances {
+		if d >= 0 {
+			waves[d] = append(waves[d], position{x: i % m.width, y: i / m.width})
+		}
+	}
+
+	var img draw.Image
+	return newAnimation(len(waves), duration,
+		func() { img = m.draw() },
+		func(i int) {
+			col := image.NewUniform(waveColor(float64(i) / float64(len(waves))))
+			for _, p := range waves[i] {
+				m.fillCell(img, p, col)
+			}
+			if i == len(waves)-1 {
+				m.drawPath(img, path)
+			}
+		},
+		render,
+	)
+}
@@ -0,0 +1,85 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"syscall/js"
+)
+
+// Pages can supply their own font, as the bytes of a TrueType or
+// OpenType file, for labels to be set in. We hand it to the browser as
+// a FontFace and draw labels with it from then on.
+
+var notAFont = errors.New("not a TrueType or OpenType font")
+
+// The signatures a font file can start with: TrueType, OpenType with
+// CFF outlines, and old Apple TrueType.
+var fontSignatures = [][]byte{
+	{0x00, 0x01, 0x00, 0x00},
+	[]byte("OTTO"),
+	[]byte("true"),
+}
+
+// How many fonts we've loaded; each gets its own family name so the
+// browser never confuses one with another.
+var fontsLoaded = 0
+
+// Load the font in the given bytes and, once the browser has it, use it
+// for labels and redraw the current maze. Returns the FontFace's load
+// promise.
+func loadFont(data []byte) (js.Value, error) {
+	ok := false
+	for _, signature := range fontSignatures {
+		ok = ok || bytes.HasPrefix(data, signature)
+	}
+	if !ok {
+		return js.Undefined(), notAFont
+	}
+
+	fontsLoaded++
+	family := fmt.Sprintf("MazeLabel%d", fontsLoaded)
+	face := js.Global().Get("FontFace").New(family, bytesToJS(data).Get("buffer"))
+
+	var loaded js.Func
+	loaded = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer loaded.Release()
+		js.Global().Get("document").Get("fonts").Call("add", face)
+		labelFont = family
+		redrawCurrent()
+		return nil
+	})
+	return face.Call("load").Call("then", loaded), nil
+}
+
+// Build the JS-facing font controls.
+func fontControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+
+	// Load a font from a Uint8Array or ArrayBuffer.
+	load := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return nil
+		}
+		promise, err := loadFont(bytesFromJS(args[0]))
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return js.Global().Get("Promise").Call("reject", err.Error())
+		}
+		return promise
+	})
+	controls.Set("load", load)
+
+	// Go back to the default font.
+	reset := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		labelFont = "serif"
+		redrawCurrent()
+		return nil
+	})
+	controls.Set("reset", reset)
+
+	return controls, []js.Func{load, reset}
+}
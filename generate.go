@@ -0,0 +1,7 @@
+package main
+
+// The directive lives here rather than in api.go, since go generate
+// skips files whose build tags don't match, and api.go only builds for
+// WASM.
+
+//go:generate go run ./cmd/mazetypes -o mazegen.d.ts api.go
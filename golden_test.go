@@ -0,0 +1,531 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The golden tests generate mazes from fixed seeds and compare them,
+// their solutions, and their renderings against the files in
+// testdata/golden. They run natively, without a browser:
+//
+//	go test
+//
+// A change that's meant to alter what gets generated or drawn will
+// fail them; check the new output is right, then rewrite the files
+// with
+//
+//	go test -update
+//
+// and commit them along with the change.
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+var goldenCases = []struct {
+	name          string
+	height, width int
+	seed          int64
+	oppositeStart bool
+	decoys        int // Decoys to grow, if any
+	solutions     int // Solutions to aim for, if more than one
+}{
+	{name: "small", height: 10, width: 10, seed: 1},
+	{name: "wide", height: 25, width: 40, seed: 42},
+	{name: "opposite", height: 20, width: 20, seed: 7, oppositeStart: true},
+	{name: "narrow", height: 30, width: 2, seed: 99},
+	{name: "decoys", height: 20, width: 20, seed: 3, decoys: 4},
+	{name: "braided", height: 15, width: 15, seed: 11, solutions: 3},
+}
+
+// The maze's cells, a row to a line, each cell a hex digit of the
+// walls it has open: north 1, south 2, east 4, west 8.
+func encodeCells(m *maze) string {
+	var b strings.Builder
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			n := 0
+			for dir, open := range m.at(position{x: x, y: y}).openings {
+				if open {
+					n |= 1 << dir
+				}
+			}
+			fmt.Fprintf(&b, "%x", n)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func hash(b []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// Everything we check about a maze, as text.
+func golden(height, width int, seed int64, oppositeStart bool, decoys, solutions int) string {
+	m := newMaze(height, width, rand.New(rand.NewSource(seed)), oppositeStart)
+	m.generate()
+	if decoys > 0 {
+		m.addDecoys(decoys)
+	}
+	if solutions > 1 {
+		m.addSolutions(solutions)
+	}
+	path := m.solution()
+
+	w, h := m.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	m.drawOnto(img)
+	m.drawPath(img, path)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "id %s\n", mazeID(height, width, seed, oppositeStart, generatorAlgorithm))
+	fmt.Fprintf(&b, "start %d,%d\n", m.start.x, m.start.y)
+	fmt.Fprintf(&b, "finish %d,%d\n", m.finish.x, m.finish.y)
+	fmt.Fprintf(&b, "solution %d\n", len(path))
+	fmt.Fprintf(&b, "difficulty %d\n", m.difficulty(path))
+	fmt.Fprintf(&b, "routes %d\n", len(m.routes(maxRoutes)))
+	fmt.Fprintf(&b, "raster %s\n", hash(img.Pix))
+	fmt.Fprintf(&b, "svg %s\n", hash([]byte(m.svg(path, ""))))
+	fmt.Fprintf(&b, "hpgl %s\n", hash([]byte(m.hpgl(path))))
+	b.WriteString(encodeCells(m))
+	return b.String()
+}
+
+func TestGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		t.Run(c.name, func(t *testing.T) {
+			got := golden(c.height, c.width, c.seed, c.oppositeStart, c.decoys, c.solutions)
+			file := filepath.Join("testdata", "golden", c.name+".txt")
+			if *update {
+				if err := os.WriteFile(file, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("%s (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s differs from %s; if that's intended, run go test -update\n got:\n%s\nwant:\n%s", c.name, file, got, want)
+			}
+		})
+	}
+}
+
+// Whether there's an open wall between two cells.
+func passage(m *maze, from, to position) bool {
+	for _, dir := range []direction{north, south, east, west} {
+		if np, err := dir.translate(from, m); err == nil && np == to {
+			return m.at(from).openings[dir]
+		}
+	}
+	return false
+}
+
+// Generating the same maze twice must agree, and its solution must
+// actually get from the start to the finish.
+func TestDeterministic(t *testing.T) {
+	for _, c := range goldenCases {
+		a := golden(c.height, c.width, c.seed, c.oppositeStart, c.decoys, c.solutions)
+		b := golden(c.height, c.width, c.seed, c.oppositeStart, c.decoys, c.solutions)
+		if a != b {
+			t.Errorf("%s: two runs from the same seed differ", c.name)
+		}
+
+		m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+		m.generate()
+		path := m.solution()
+		if len(path) == 0 || path[0] != m.start || path[len(path)-1] != m.finish {
+			t.Errorf("%s: solution doesn't run from start to finish", c.name)
+			continue
+		}
+		for i := 1; i < len(path); i++ {
+			if !passage(m, path[i-1], path[i]) {
+				t.Errorf("%s: solution goes through a wall at step %d", c.name, i)
+				break
+			}
+		}
+	}
+}
+
+// Every algorithm must reach every cell of the maze, and a maze's ID
+// must name the maze again, whichever algorithm made it.
+func TestAlgorithms(t *testing.T) {
+	for _, g := range generators {
+		for _, c := range goldenCases {
+			m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+			m.generateWith(g.name)
+
+			for i, d := range m.distances(m.start) {
+				if d < 0 {
+					t.Errorf("%s, %s: cell %d is unreachable", g.name, c.name, i)
+					break
+				}
+			}
+
+			id := mazeID(m.height, m.width, c.seed, c.oppositeStart, m.algorithm)
+			if again, err := mazeFromID(id); err != nil || encodeCells(again) != encodeCells(m) {
+				t.Errorf("%s, %s: maze ID %s doesn't name the same maze", g.name, c.name, id)
+			}
+		}
+	}
+}
+
+// Braiding every dead end must leave none, and the solution of the
+// braided maze must be a shortest one.
+func TestBraid(t *testing.T) {
+	for _, c := range goldenCases {
+		m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+		m.generate()
+		m.braid(1)
+
+		for i := range m.cells {
+			if p := (position{x: i % m.width, y: i / m.width}); m.branches(p) < 2 {
+				t.Errorf("%s: %v is still a dead end", c.name, p)
+				break
+			}
+		}
+		path := m.solution()
+		if want := m.distances(m.start)[m.finish.y*m.width+m.finish.x] + 1; len(path) != want {
+			t.Errorf("%s: solution has %d cells, the shortest has %d", c.name, len(path), want)
+		}
+	}
+}
+
+// Shaped mazes must reach every cell, and be solved from the start to
+// the finish through open walls.
+func TestShapedMazes(t *testing.T) {
+	for _, kind := range []string{hexTopology, thetaTopology, levelsTopology} {
+		for _, c := range goldenCases {
+			m := newShapedMaze(shapedGrid(kind, c.height, c.width, 3), rand.New(rand.NewSource(c.seed)))
+			m.generate()
+
+			passages := 0
+			for i, sides := range m.open {
+				for side, open := range sides {
+					if open && m.adj[i][side] >= 0 {
+						passages++
+					}
+				}
+			}
+			if passages != 2*(len(m.adj)-1) {
+				t.Errorf("%s, %s: %d passages between %d cells", kind, c.name, passages/2, len(m.adj))
+			}
+
+			path := m.solution()
+			if path[0] != m.start || path[len(path)-1] != m.finish {
+				t.Errorf("%s, %s: solution doesn't run from start to finish", kind, c.name)
+			}
+			m.draw()
+		}
+	}
+}
+
+// A masked maze must keep to its mask, be solvable from a start and
+// finish inside it, and say it was carved with the backtracker, whatever
+// algorithm was asked for.
+func TestMask(t *testing.T) {
+	k, err := parseTextMask(" ## \n####\n.##.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range goldenCases {
+		m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+		m.applyMask(k.sample(m.height, m.width))
+		m.generateWith(generators[1].name)
+
+		if m.algorithm != generatorAlgorithm {
+			t.Errorf("%s: masked maze says it was carved with %s", c.name, m.algorithm)
+		}
+		if !m.inMask(m.start) || !m.inMask(m.finish) {
+			t.Errorf("%s: start or finish outside the mask", c.name)
+		}
+		for i, d := range m.distances(m.start) {
+			if p := (position{x: i % m.width, y: i / m.width}); (d >= 0) != m.inMask(p) {
+				t.Errorf("%s: %v is reachable %v but in the mask %v", c.name, p, d >= 0, m.inMask(p))
+				break
+			}
+		}
+		if path := m.solution(); path[len(path)-1] != m.finish {
+			t.Errorf("%s: solution doesn't reach the finish", c.name)
+		}
+	}
+}
+
+// A maze laid out on paper must fit inside the margins, centred, and
+// keep its walls the width asked for.
+func TestPaper(t *testing.T) {
+	for _, c := range goldenCases {
+		m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+		m.generate()
+		width, height := m.imageSize()
+
+		l := pageSetup{paper: "a4", lineWidth: 0.5}.layout(width, height, 1)
+		right, bottom := l.x+float64(width)*l.scale, l.y+float64(height)*l.scale
+		if l.x < paperMargin-1e-9 || l.y < paperMargin-1e-9 || right > 210-paperMargin+1e-9 || bottom > 297-paperMargin+1e-9 {
+			t.Errorf("%s: maze at (%g, %g)-(%g, %g) isn't inside the margins", c.name, l.x, l.y, right, bottom)
+		}
+		if d := l.x + right - 210; d*d > 1e-9 {
+			t.Errorf("%s: maze isn't centred across the page", c.name)
+		}
+		if d := l.stroke*l.scale - 0.5; d*d > 1e-9 {
+			t.Errorf("%s: walls are %gmm wide, not 0.5mm", c.name, l.stroke*l.scale)
+		}
+		if svg := m.svgPage(nil, "", pageSetup{paper: "a4"}); !strings.Contains(svg, `width="210mm" height="297mm"`) {
+			t.Errorf("%s: SVG isn't A4", c.name)
+		}
+	}
+}
+
+// A theme's cells and border must set the image's size, the biggest
+// must still keep images drawn whole within maxDrawnSide, its colors
+// must survive being written as CSS and read back and color SVGs, and
+// the default theme must put everything back as it was.
+func TestTheme(t *testing.T) {
+	defer useTheme(defaultTheme)
+
+	c := goldenCases[1]
+	before := golden(c.height, c.width, c.seed, c.oppositeStart, c.decoys, c.solutions)
+	m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+
+	useTheme(theme{cellSize: 20, border: 30, lineWidth: 3, wall: color.RGBA{1, 2, 3, 255}, background: color.RGBA{250, 250, 240, 255}, solution: color.RGBA{0, 128, 255, 255}})
+	if width, height := m.imageSize(); width != m.width*20+60 || height != m.height*20+60 {
+		t.Errorf("image is %dx%d with 20-pixel cells and a 30-pixel border", width, height)
+	}
+	svg := m.svg([]position{m.start, m.finish}, "")
+	for _, col := range []color.RGBA{currentTheme.wall, currentTheme.background, currentTheme.solution} {
+		if again, err := parseColor(cssColor(col)); err != nil || again != col {
+			t.Errorf("%v came back from %s as %v", col, cssColor(col), again)
+		}
+		if !strings.Contains(svg, `"`+cssColor(col)+`"`) {
+			t.Errorf("SVG isn't drawn in %s", cssColor(col))
+		}
+	}
+
+	useTheme(theme{cellSize: maxCellSize, border: maxBorder, lineWidth: 1})
+	if big := newMaze(maxDrawnDimension, maxDrawnDimension, rand.New(rand.NewSource(1)), false); !big.viewOnly() {
+		t.Errorf("%dx%d maze drawn whole with the biggest cells", maxDrawnDimension, maxDrawnDimension)
+	}
+	n := maxDrawnCells()
+	if width, height := newMaze(n, n, rand.New(rand.NewSource(1)), false).imageSize(); width > maxDrawnSide || height > maxDrawnSide {
+		t.Errorf("%dx%d maze drawn whole is %dx%d pixels", n, n, width, height)
+	}
+
+	useTheme(defaultTheme)
+	if golden(c.height, c.width, c.seed, c.oppositeStart, c.decoys, c.solutions) != before {
+		t.Error("the default theme doesn't draw as before")
+	}
+}
+
+// A maze code must bring back the same maze, whatever has been done to
+// it since it was generated, and refuse codes that have been damaged.
+func TestMazeCode(t *testing.T) {
+	k, _ := parseTextMask(" ## \n####\n.##.\n")
+	for i, c := range goldenCases {
+		m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+		if i%2 == 1 {
+			m.applyMask(k.sample(m.height, m.width))
+		}
+		m.generateWith(generators[i%len(generators)].name)
+		if c.decoys > 0 {
+			m.addDecoys(c.decoys)
+		}
+		if c.solutions > 1 {
+			m.addSolutions(c.solutions)
+		}
+
+		code := m.encode(c.seed)
+		again, seed, err := decodeMaze(code)
+		switch {
+		case err != nil:
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		case encodeCells(again) != encodeCells(m) || again.start != m.start || again.finish != m.finish:
+			t.Errorf("%s: decoded maze isn't the same", c.name)
+		case seed != c.seed || again.oppositeStart != c.oppositeStart || again.algorithm != m.algorithm || again.loops != m.loops:
+			t.Errorf("%s: decoded maze's parameters aren't the same", c.name)
+		case len(again.solution()) != len(m.solution()):
+			t.Errorf("%s: decoded maze solves differently", c.name)
+		}
+
+		if _, _, err := decodeMaze(code[:len(code)-2]); err == nil {
+			t.Errorf("%s: truncated code decoded", c.name)
+		}
+	}
+
+	// Codes must be of mazes that can be solved, with no passages out
+	// of their masks.
+	walled := newMaze(3, 3, rand.New(rand.NewSource(1)), false)
+	if _, _, err := decodeMaze(walled.encode(1)); err == nil {
+		t.Error("code of a maze with no way through decoded")
+	}
+	leaky := newMaze(3, 3, rand.New(rand.NewSource(1)), true)
+	leaky.generate()
+	leaky.mask = []bool{true, true, true, true, false, true, true, true, true}
+	if _, _, err := decodeMaze(leaky.encode(1)); err == nil {
+		t.Error("code of a maze with passages out of its mask decoded")
+	}
+
+	m := newMaze(300, 260, rand.New(rand.NewSource(5)), false)
+	m.generate()
+	if again, _, err := decodeMaze(m.encode(5)); err != nil || encodeCells(again) != encodeCells(m) || again.finish != m.finish {
+		t.Errorf("maze too big for a byte's code didn't come back: %v", err)
+	}
+}
+
+// A braided maze isn't the maze its ID rebuilds, so its solution must
+// be verified against its code, and must pass.
+func TestVerifyReshaped(t *testing.T) {
+	m := newMaze(15, 15, rand.New(rand.NewSource(9)), false)
+	m.generate()
+	m.braid(0.5)
+	if !m.reshaped() {
+		t.Fatal("braided maze isn't reshaped")
+	}
+
+	var moves strings.Builder
+	path := m.solution()
+	for i := 1; i < len(path); i++ {
+		for r, d := range moveLetters {
+			if np, err := d.translate(path[i-1], m); err == nil && np == path[i] {
+				moves.WriteRune(r)
+			}
+		}
+	}
+	if err := verifySolution(m.encode(9), moves.String()); err != nil {
+		t.Errorf("solution of a braided maze didn't verify: %v", err)
+	}
+}
+
+// Grading a maze small enough to check by hand: the solution runs
+// straight down from the start, and a false branch winds around
+// through the other four cells.
+func TestGrade(t *testing.T) {
+	m := newMaze(2, 3, rand.New(rand.NewSource(1)), false)
+	m.start, m.finish = position{0, 0}, position{0, 1}
+	m.carve(position{0, 0}, south)
+	m.carve(position{0, 0}, east)
+	m.carve(position{1, 0}, east)
+	m.carve(position{2, 0}, south)
+	m.carve(position{2, 1}, west)
+	m.openEnds()
+
+	a := m.analyze()
+	got := fmt.Sprintf("solution %d, dead ends %d, junctions %d, longest branch %d, distances %v",
+		a.solution, a.deadEnds, a.junctions, a.longestBranch, a.distances)
+	if want := "solution 2, dead ends 2, junctions 0, longest branch 4, distances [0 1 2 1 4 3]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// Measured as a tree, a perfect maze must have the diameter and
+// average path that searching from every cell finds.
+func TestAnalyzeTree(t *testing.T) {
+	m := newMaze(20, 20, rand.New(rand.NewSource(1)), false)
+	m.generateWith(generators[1].name)
+	if m.passages() != len(m.cells)-1 {
+		t.Fatalf("%s maze isn't a tree", m.algorithm)
+	}
+	tree, all := m.analyzeTree(), m.analyzeAllPairs()
+	if tree.diameter != all.diameter || math.Abs(tree.averagePath-all.averagePath) > 1e-9 {
+		t.Errorf("as a tree: diameter %d, average %f; searching every cell: diameter %d, average %f",
+			tree.diameter, tree.averagePath, all.diameter, all.averagePath)
+	}
+}
+
+// Drawn through a viewport showing all of it at zoom 1, a maze must
+// look just as it does drawn whole, however thick its lines.
+func TestViewport(t *testing.T) {
+	defer useTheme(defaultTheme)
+
+	c := goldenCases[4]
+	m := newMaze(c.height, c.width, rand.New(rand.NewSource(c.seed)), c.oppositeStart)
+	m.generate()
+	m.addDecoys(c.decoys)
+	path := m.solution()
+
+	for _, th := range []theme{defaultTheme, {cellSize: 20, border: 30, lineWidth: 3, wall: color.RGBA{1, 2, 3, 255}, background: color.RGBA{250, 250, 240, 255}, solution: color.RGBA{0, 128, 255, 255}}} {
+		useTheme(th)
+		w, h := m.imageSize()
+		whole := image.NewRGBA(image.Rect(0, 0, w, h))
+		m.drawOnto(whole)
+		m.drawPath(whole, path)
+
+		view := image.NewRGBA(whole.Rect)
+		m.drawView(view, viewport{zoom: 1, width: w, height: h}, m.pathSteps(path))
+		if hash(view.Pix) != hash(whole.Pix) {
+			t.Errorf("%d-pixel lines: viewport doesn't match the whole image", th.lineWidth)
+		}
+	}
+}
+
+// Every MazeExportFormat must have an exporter, and every exporter a
+// format. The API only builds for WASM, so like cmd/mazetypes this
+// reads api.go's source.
+func TestExportFormats(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "api.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := func(name string) []ast.Expr {
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					if value.Names[0].Name == name && len(value.Values) == 1 {
+						return value.Values[0].(*ast.CompositeLit).Elts
+					}
+				}
+			}
+		}
+		t.Fatalf("%s not found", name)
+		return nil
+	}
+	str := func(e ast.Expr) string {
+		lit, _ := e.(*ast.BasicLit)
+		if lit == nil || lit.Kind != token.STRING {
+			return ""
+		}
+		s, _ := strconv.Unquote(lit.Value)
+		return s
+	}
+
+	var formats, exported []string
+	for _, entry := range table("apiTypes") {
+		fields := make(map[string]string)
+		for _, field := range entry.(*ast.CompositeLit).Elts {
+			kv := field.(*ast.KeyValueExpr)
+			fields[kv.Key.(*ast.Ident).Name] = str(kv.Value)
+		}
+		if fields["name"] == "MazeExportFormat" {
+			for _, f := range strings.Split(fields["definition"], "|") {
+				formats = append(formats, strings.Trim(f, ` "`))
+			}
+		}
+	}
+	for _, entry := range table("exporters") {
+		exported = append(exported, str(entry.(*ast.KeyValueExpr).Key))
+	}
+
+	sort.Strings(formats)
+	sort.Strings(exported)
+	if len(formats) == 0 || strings.Join(formats, " ") != strings.Join(exported, " ") {
+		t.Errorf("formats %v, but exporters for %v", formats, exported)
+	}
+}
@@ -0,0 +1,150 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"strconv"
+	"syscall/js"
+)
+
+// The generator remembers the last few mazes it generated, with a
+// thumbnail of each, so that pages can show a gallery of them and
+// bring one back full size. Each is kept as its maze code (see
+// codec.go), which carries it as it was drawn, mask, decoys, braiding
+// and all, along with the settings and theme it was drawn with, so
+// restoring one draws it again just as it was.
+
+const (
+	maxHistory    = 12  // Mazes remembered
+	thumbnailSize = 128 // Width/height (in pixels) thumbnails are fit into
+)
+
+type historyEntry struct {
+	id        string // "" if it has none; see (*maze).id
+	code      string
+	args      arguments
+	theme     theme
+	world     *world // The world the maze is the first room of, if any
+	thumbnail *image.RGBA
+}
+
+// The remembered mazes, most recent first.
+var history []historyEntry = nil
+
+// If set, a JS function called with the history whenever it changes.
+var historyHook js.Value = js.Undefined()
+
+// Remember a newly generated maze. Generating a remembered maze again
+// (as restoring it does) moves it to the front rather than adding it
+// twice.
+func remember(m *maze, seed int64, args arguments) {
+	entry := historyEntry{
+		id:    m.id(seed),
+		code:  m.encode(seed),
+		args:  args,
+		theme: currentTheme,
+		world: currentWorld,
+	}
+
+	kept := []historyEntry{entry}
+	for _, e := range history {
+		if e.code == entry.code && e.theme == entry.theme {
+			kept[0].thumbnail = e.thumbnail
+			continue
+		}
+		if len(kept) < maxHistory {
+			kept = append(kept, e)
+		}
+	}
+	if kept[0].thumbnail == nil {
+		kept[0].thumbnail = m.thumbnail(thumbnailSize)
+	}
+	history = kept
+
+	if historyHook.Type() == js.TypeFunction {
+		historyHook.Invoke(historyToJS())
+	}
+}
+
+func historyToJS() js.Value {
+	entries := js.Global().Get("Array").New()
+	for _, e := range history {
+		b := e.thumbnail.Bounds()
+		pixels := js.Global().Get("Uint8ClampedArray").New(bytesToJS(e.thumbnail.Pix).Get("buffer"))
+
+		v := js.Global().Get("Object").New()
+		v.Set("id", js.Null())
+		if e.id != "" {
+			v.Set("id", e.id)
+		}
+		v.Set("code", e.code)
+		v.Set("decoys", e.args.decoys)
+		v.Set("solutions", e.args.targetSolutions)
+		v.Set("braid", e.args.braid)
+		v.Set("thumbnail", js.Global().Get("ImageData").New(pixels, b.Dx(), b.Dy()))
+		entries.Call("push", v)
+	}
+	return entries
+}
+
+// Draw a remembered maze again, as it was, and put its settings back
+// on the page.
+func restore(i int) bool {
+	if i < 0 || i >= len(history) {
+		return false
+	}
+	e := history[i]
+	m, seed, err := decodeMaze(e.code)
+	if err != nil {
+		return false
+	}
+
+	setDimension("mazeHeight", m.height)
+	setDimension("mazeWidth", m.width)
+	setInput("randomSeed", "value", strconv.FormatInt(seed, 10))
+	setInput("oppositeStart", "checked", m.oppositeStart)
+	setInput("algorithm", "value", m.algorithm)
+	setInput("decoys", "value", e.args.decoys)
+	setInput("targetSolutions", "value", e.args.targetSolutions)
+	setInput("braid", "value", e.args.braid)
+
+	useTheme(e.theme)
+	currentWorld = e.world
+	if currentWorld != nil {
+		currentWorld.rooms[0] = m
+	}
+	renderMaze(m, seed, e.args)
+	currentID = m.id(seed)
+	currentShaping.decoys, currentShaping.solutions, currentShaping.braid = e.args.decoys, e.args.targetSolutions, e.args.braid
+	remember(m, seed, e.args)
+	if e.args.play {
+		focusMaze()
+	}
+	return true
+}
+
+// history() returns the remembered mazes, most recent first.
+func historyJS(this js.Value, args []js.Value) interface{} {
+	return historyToJS()
+}
+
+// onHistory(fn) calls fn with the history whenever it changes; null
+// stops it.
+func onHistoryJS(this js.Value, args []js.Value) interface{} {
+	historyHook = js.Undefined()
+	if len(args) > 0 && args[0].Type() == js.TypeFunction {
+		historyHook = args[0]
+	}
+	return nil
+}
+
+// restore(index) brings back the maze at the given place in the
+// history, full size.
+func restoreJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeNumber {
+		return false
+	}
+	return restore(args[0].Int())
+}
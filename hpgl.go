@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	hpglUnitsPerMM = 40                   // HPGL plotter units are 0.025mm
+	hpglPlotWidth  = 277 * hpglUnitsPerMM // A4 landscape, less a 10mm margin on each side
+	hpglPlotHeight = 190 * hpglUnitsPerMM // ...
+	hpglOrigin     = 10 * hpglUnitsPerMM  // Offset of the plot from the corner of the paper
+	hpglMaxCell    = 10 * hpglUnitsPerMM  // Cells are never drawn larger than 10mm
+)
+
+// Order segments to minimize pen-up travel between them.
+//
+// This is a greedy nearest-neighbor walk: starting from the origin,
+// we always draw next whichever remaining segment has an endpoint
+// closest to where the pen is now, drawing it in whichever direction
+// starts at that endpoint. Ordered segments therefore run from
+// (x0, y0) to (x1, y1) but may run in either direction.
+//
+// It's not optimal, but on a 100x100 maze it cuts the travel by
+// several times over the order walls() produces them in.
+func orderSegments(segments []segment) []segment {
+	defer tr(ace("ordering segments"))
+
+	remaining := append([]segment(nil), segments...)
+	ordered := make([]segment, 0, len(segments))
+	x, y := 0, 0
+	for len(remaining) > 0 {
+		best, bestDistance, reverse := 0, -1, false
+		for i, s := range remaining {
+			if d := distance(x, y, s.x0, s.y0); bestDistance < 0 || d < bestDistance {
+				best, bestDistance, reverse = i, d, false
+			}
+			if d := distance(x, y, s.x1, s.y1); d < bestDistance {
+				best, bestDistance, reverse = i, d, true
+			}
+			if bestDistance == 0 {
+				break
+			}
+		}
+
+		s := remaining[best]
+		if reverse {
+			s = segment{s.x1, s.y1, s.x0, s.y0}
+		}
+		ordered = append(ordered, s)
+		x, y = s.x1, s.y1
+
+		remaining[best] = remaining[len(remaining)-1]
+		remaining = remaining[:len(remaining)-1]
+	}
+
+	return ordered
+}
+
+// Squared distance between two points; we only ever compare these.
+func distance(x0, y0, x1, y1 int) int {
+	return (x1-x0)*(x1-x0) + (y1-y0)*(y1-y0)
+}
+
+// Render the maze as HPGL for a pen plotter, scaled to fit on A4.
+// Walls are drawn with pen 1 and, if path is not nil, the solution
+// with pen 2.
+func (m *maze) hpgl(path []position) string {
+	defer tr(ace("rendering hpgl"))
+
+	cell := hpglPlotWidth / m.width
+	if c := hpglPlotHeight / m.height; c < cell {
+		cell = c
+	}
+	if cell > hpglMaxCell {
+		cell = hpglMaxCell
+	}
+
+	// HPGL's Y axis points up, so flip the maze over.
+	px := func(x int) int { return hpglOrigin + x*cell }
+	py := func(y int) int { return hpglOrigin + (m.height-y)*cell }
+
+	var b strings.Builder
+	b.WriteString("IN;SP1;")
+
+	penX, penY := -1, -1
+	for _, s := range orderSegments(m.walls()) {
+		if s.x0 != penX || s.y0 != penY {
+			fmt.Fprintf(&b, "PU%d,%d;", px(s.x0), py(s.y0))
+		}
+		fmt.Fprintf(&b, "PD%d,%d;", px(s.x1), py(s.y1))
+		penX, penY = s.x1, s.y1
+	}
+
+	if len(path) > 0 {
+		half := cell / 2
+		fmt.Fprintf(&b, "PU;SP2;PU%d,%d;PD", px(path[0].x)+half, py(path[0].y)-half)
+		for i, p := range path[1:] {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "%d,%d", px(p.x)+half, py(p.y)-half)
+		}
+		b.WriteString(";")
+	}
+
+	b.WriteString("PU;SP0;\n")
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Render the maze as a standalone HTML snippet: a CSS grid with a
+// div per cell, and the walls as cell borders, so it can be embedded
+// in a page, scales with its container, and can be restyled with CSS.
+// Each wall is drawn once, by the cell to its south or east, except
+// round the bottom and right edges. Cells on the solution have the
+// maze-solution class, which only shows when the maze's container
+// also has the solved class.
+func (m *maze) htmlGrid(path []position) string {
+	defer tr(ace("rendering html grid"))
+
+	onPath := make([]bool, len(m.cells))
+	for _, p := range path {
+		onPath[p.y*m.width+p.x] = true
+	}
+
+	var b strings.Builder
+	b.WriteString(`<style>
+.maze { display: grid; max-width: 100%; }
+.maze > div { aspect-ratio: 1; box-sizing: border-box; border: 0 solid black; }
+.maze .n { border-top-width: 1px; }
+.maze .w { border-left-width: 1px; }
+.maze .s { border-bottom-width: 1px; }
+.maze .e { border-right-width: 1px; }
+.maze.solved .maze-solution { background: #f88; }
+</style>
+`)
+	fmt.Fprintf(&b, `<div class="maze" style="grid-template-columns: repeat(%d, 1fr);">`+"\n", m.width)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			c := m.at(position{x: x, y: y})
+			var classes []string
+			if !c.openings[north] {
+				classes = append(classes, "n")
+			}
+			if !c.openings[west] {
+				classes = append(classes, "w")
+			}
+			if y == m.height-1 && !c.openings[south] {
+				classes = append(classes, "s")
+			}
+			if x == m.width-1 && !c.openings[east] {
+				classes = append(classes, "e")
+			}
+			if onPath[y*m.width+x] {
+				classes = append(classes, "maze-solution")
+			}
+			fmt.Fprintf(&b, `<div class="%s"></div>`, strings.Join(classes, " "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("</div>\n")
+	return b.String()
+}
@@ -10,12 +10,10 @@
 
 // Global variables, populated during setup and manipulated during generation.
 var exports = null
-const canvasElement = document.querySelector("canvas");
-const canvasContext = canvasElement.getContext("2d");
-var canvasImageData = canvasContext.createImageData(
-    canvasElement.width,
-    canvasElement.height
-);
+// The canvas we draw on, which our WASM code passes to putMaze.
+var canvasElement = document.getElementById("targetCanvas");
+var canvasContext = canvasElement.getContext("2d");
+var canvasImageData = undefined;
 
 // The actual pixels we're going to draw; a view onto an RGBA buffer.
 var pixels = undefined;
@@ -36,18 +34,22 @@ var lastSize = undefined;
 // The ImageData we use to populate the canvas.
 var imageData = undefined;
 
-// Populate the initial font.
-canvasContext.font = "10px serif";
-
 // A function to instantiation a WASM module, working around various
 // cross-browser problems.
 const wasmBrowserInstantiate = async (wasmModuleUrl, importObject) => {
     let response = undefined;
 
     const fetchAndInstantiateTask = async () => {
-        const wasmArrayBuffer = await fetch(wasmModuleUrl).then(response =>
-                response.arrayBuffer()
-        );
+        // Single-file builds (see cmd/mazepack) carry the module with
+        // them, in base64.
+        let wasmArrayBuffer = undefined;
+        if (window.mazeModule) {
+            wasmArrayBuffer = Uint8Array.from(atob(mazeModule), c => c.charCodeAt(0));
+        } else {
+            wasmArrayBuffer = await fetch(wasmModuleUrl).then(response =>
+                    response.arrayBuffer()
+            );
+        }
 
         var buffer = pako.ungzip(wasmArrayBuffer);
         // A fetched response might be decompressed twice on Firefox.
@@ -72,8 +74,56 @@ function exportMaze() {
     }, 0);
 }
 
+// Called by our WASM code to hand a generated file to the user.
+function offerDownload(name, type, data) {
+    let url = URL.createObjectURL(new Blob([data], {type: type}));
+    let a = document.createElement("a");
+    a.href = url;
+    a.download = name;
+    a.click();
+    setTimeout(function(){
+        URL.revokeObjectURL(url);
+    }, 0);
+}
+
+// Record the next animation as a video. We capture frames only when
+// the animation exports them, so the video has exactly one frame per
+// animation frame no matter how long each takes to draw.
+function recordAnimation() {
+    let stream = canvasElement.captureStream(0);
+    let track = stream.getVideoTracks()[0];
+    let recorder = new MediaRecorder(stream);
+    let chunks = [];
+
+    recorder.ondataavailable = function(e) {
+        chunks.push(e.data);
+    };
+    recorder.onstop = function() {
+        offerDownload("maze.webm", recorder.mimeType, new Blob(chunks, {type: recorder.mimeType}));
+    };
+
+    mazeAnimation.onFrame(function(frame) {
+        track.requestFrame();
+        if (frame.done) {
+            mazeAnimation.onFrame(null);
+            // Give the recorder a moment to take the last frame.
+            setTimeout(function(){
+                recorder.stop();
+            }, 100);
+        }
+    });
+    recorder.start();
+}
+
 // Called by our WASM code to paint the maze.
-function putMaze(newMazeHeight, newMazeWidth, newPointer, newSize, label) {
+function putMaze(canvas, newMazeHeight, newMazeWidth, newPointer, newSize) {
+
+    // Switch canvases if we've been told to draw somewhere else.
+    if (canvas !== canvasElement) {
+        canvasElement = canvas;
+        canvasContext = canvasElement.getContext("2d");
+        lastHeight = undefined;
+    }
 
     // Resize the canvas if needed.
     if (newMazeHeight != lastHeight || newMazeWidth != lastWidth || !canvasImageData) {
@@ -90,8 +140,11 @@ function putMaze(newMazeHeight, newMazeWidth, newPointer, newSize, label) {
         lastPointer = undefined;
     }
     
-    // Rebuild the view onto the framebuffer if needed. FIXME - is testing byteLength portable?
-    if (!pixels || lastPointer != newPointer || lastSize != newSize || pixels.byteLength == 0) {
+    // Rebuild the view onto the framebuffer if needed. When WASM memory
+    // grows, its old ArrayBuffer is detached and a new one takes its
+    // place, so a view onto the old one is useless even if the frame
+    // buffer hasn't moved.
+    if (!pixels || lastPointer != newPointer || lastSize != newSize || pixels.buffer !== exports.mem.buffer) {
         console.log("rebuilding view ", " lastPointer = ", lastPointer, " newPointer = ", newPointer);
         pixels = new Uint8ClampedArray(
             exports.mem.buffer,
@@ -106,15 +159,135 @@ function putMaze(newMazeHeight, newMazeWidth, newPointer, newSize, label) {
     canvasImageData.data.set(pixels);
     canvasContext.putImageData(canvasImageData, 0, 0);
     
-    // Draw the label.
-    if (label) {
-        canvasContext.fillText(label, 40, 39);
-    }
-    
-    // Enable the export button.
-    document.getElementById("exportButton").disabled = false;
+    enableExports();
 };
 
+// Enable the export buttons; called once there's a maze to export.
+function enableExports() {
+    for (let button of document.querySelectorAll("button.export")) {
+        button.disabled = false;
+    }
+}
+
+// Disable the exports of the whole maze; called for mazes too big to
+// draw whole, which can still be exported as the canvas shows them.
+function disableWholeExports() {
+    for (let button of document.querySelectorAll("button.whole")) {
+        button.disabled = true;
+    }
+}
+
+// Disable the exports of what's on the raster canvas; called while the
+// WebGL canvas shows the maze instead.
+function disableCanvasExports() {
+    for (let button of document.querySelectorAll("button.canvas")) {
+        button.disabled = true;
+    }
+}
+
+// Show the thumbnails of recently generated mazes in the gallery.
+function showHistory(entries) {
+    let gallery = document.getElementById("history");
+    gallery.replaceChildren();
+    entries.forEach(function(entry, i) {
+        let canvas = document.createElement("canvas");
+        canvas.width = entry.thumbnail.width;
+        canvas.height = entry.thumbnail.height;
+        canvas.title = entry.id;
+        canvas.getContext("2d").putImageData(entry.thumbnail, 0, 0);
+        canvas.addEventListener("click", function() {
+            MazeGen.restore(i);
+        });
+        gallery.appendChild(canvas);
+    });
+}
+
+// Shape new mazes to a mask file: text as it is, and images read into
+// ImageData. No file goes back to rectangles.
+async function loadMask(file) {
+    let mask = null;
+    if (file && file.type.startsWith("image/")) {
+        let bitmap = await createImageBitmap(file);
+        let canvas = new OffscreenCanvas(bitmap.width, bitmap.height);
+        let context = canvas.getContext("2d");
+        context.drawImage(bitmap, 0, 0);
+        mask = context.getImageData(0, 0, bitmap.width, bitmap.height);
+    } else if (file) {
+        mask = await file.text();
+    }
+    let error = MazeGen.setMask(mask);
+    if (error) {
+        alert(error);
+    }
+}
+
+// Show the moves and time so far beside the play mode checkbox.
+function showGameStatus(status) {
+    let seconds = (status.time / 1000).toFixed(1);
+    let text = status.moves + " moves, " + seconds + " s";
+    if (status.finished) {
+        text += ", finished!";
+    } else if (status.failed) {
+        text += ", out of moves";
+    }
+    document.getElementById("playMode").nextElementSibling.value = text;
+}
+
+// Put the current maze's code in the URL's fragment, so the page's
+// address brings it back, and copy the address.
+function shareMaze() {
+    let code = MazeGen.encode();
+    if (!code) {
+        return;
+    }
+    history.replaceState(null, "", "#maze=" + code);
+    if (navigator.clipboard) {
+        navigator.clipboard.writeText(location.href);
+    }
+}
+
+// Draw the maze whose code is in the URL's fragment, if there is one.
+function loadSharedMaze() {
+    if (location.hash.startsWith("#maze=")) {
+        MazeGen.decode(location.hash.slice("#maze=".length));
+    }
+}
+
+// Pan mazes too big to draw whole by dragging them, and zoom them with
+// the mouse wheel. Canvas pixels can be bigger or smaller on the page
+// than they are, so movements are scaled to them.
+function listenViewport() {
+    let dragging = null;
+    let scale = function() {
+        return canvasElement.width / canvasElement.getBoundingClientRect().width;
+    };
+    canvasElement.addEventListener("pointerdown", function(event) {
+        if (MazeGen.view()) {
+            dragging = {x: event.clientX, y: event.clientY};
+            canvasElement.setPointerCapture(event.pointerId);
+        }
+    });
+    canvasElement.addEventListener("pointermove", function(event) {
+        if (dragging) {
+            let s = scale();
+            MazeGen.pan((dragging.x - event.clientX) * s, (dragging.y - event.clientY) * s);
+            dragging = {x: event.clientX, y: event.clientY};
+        }
+    });
+    canvasElement.addEventListener("pointerup", function() {
+        dragging = null;
+    });
+    canvasElement.addEventListener("wheel", function(event) {
+        if (!MazeGen.view()) {
+            return;
+        }
+        event.preventDefault();
+        let rect = canvasElement.getBoundingClientRect();
+        let s = scale();
+        MazeGen.zoom(event.deltaY < 0 ? 1.25 : 0.8, (event.clientX - rect.left) * s, (event.clientY - rect.top) * s);
+    }, {passive: false});
+}
+
 // Defined in wasm_exec.js.
 const go = new Go();
 
@@ -132,9 +305,36 @@ const runWasm = async () => {
     // Get our exports object, with all of our exported Wasm Properties
     exports = wasmModule.instance.exports;
 
-    // Get our canvas element from our index.html
+    // Attach to our canvas and the settings form.
+    MazeGen.init(canvasElement, {reserveMemory: false});
     canvasContext.clearRect(0, 0, canvasElement.width, canvasElement.height);
     
+    // Buzz on phones as the player bumps into walls, reaches junctions,
+    // and finishes the maze.
+    if (navigator.vibrate) {
+        const patterns = {
+            bump: [40],
+            junction: [15, 30, 15],
+            finish: [100, 50, 100, 50, 200],
+        };
+        mazeGame.onEvent(function(event) {
+            navigator.vibrate(patterns[event.type]);
+        });
+    }
+    
+    // Show recent mazes; clicking one brings it back.
+    MazeGen.onHistory(showHistory);
+
+    // Keep score in play mode.
+    MazeGen.onMove(showGameStatus);
+
+    // Mazes too big to draw whole can be dragged and zoomed.
+    listenViewport();
+
+    // Links to mazes bring them back too.
+    loadSharedMaze();
+    window.addEventListener("hashchange", loadSharedMaze);
+
     // Enable the generate button.
 	document.getElementById("generateButton").disabled = false;
 };
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// The junction overlay marks every decision point in the maze: a dot
+// in each cell with three or four ways out, bigger for four. Clusters
+// of dots show where the puzzle's complexity is. Only the raster
+// renderer draws it.
+
+// Whether to draw the junction overlay.
+var showJunctions = false
+
+var junctionColor = image.NewUniform(color.RGBA{0, 150, 0, 255})
+
+// The number of passages out of a cell.
+func (m *maze) branches(p position) int {
+	n := 0
+	for _, d := range []direction{north, south, east, west} {
+		if _, err := d.translate(p, m); err == nil && m.at(p).openings[d] {
+			n++
+		}
+	}
+	return n
+}
+
+// Draw a dot on every junction.
+func (m *maze) drawJunctions(img draw.Image) {
+	defer tr(ace("drawing junctions"))
+
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			n := m.branches(position{x: x, y: y})
+			if n < 3 {
+				continue
+			}
+
+			// Three ways is a 2x2 dot, four ways a 4x4 one.
+			r := n - 2
+			cx := x*cellWidth + border + halfCellWidth
+			cy := y*cellWidth + border + halfCellWidth
+			draw.Draw(img, image.Rect(cx-r+1, cy-r+1, cx+r+1, cy+r+1), junctionColor, image.Point{0, 0}, draw.Over)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+)
+
+// Everything the page can do can be done from the keyboard. Arrow keys
+// move the player in playable mode; single letters do the rest.
+
+const resizeStep = 5 // Cells added or removed by the resize shortcuts
+
+// Keys that move the player.
+var moveKeys = map[string]direction{
+	"ArrowUp":    north,
+	"ArrowDown":  south,
+	"ArrowRight": east,
+	"ArrowLeft":  west,
+}
+
+var shortcuts = map[string]func(){
+	"g": generateCallback,
+	"s": func() { toggle("showSolution") },
+	"p": func() { toggle("playMode") },
+	"h": func() {
+		if currentGame != nil {
+			currentGame.hint()
+		}
+	},
+	"d": func() {
+		if !glShown {
+			js.Global().Call("exportMaze")
+		}
+	},
+	"+": func() { resize(resizeStep) },
+	"=": func() { resize(resizeStep) },
+	"-": func() { resize(-resizeStep) },
+	"?": func() { announce(shortcutHelp) },
+}
+
+const shortcutHelp = "Keyboard shortcuts: G generates a maze, S shows or hides the solution, " +
+	"P starts or stops playing, arrow keys move while playing, H gives a hint while playing, D downloads the image, " +
+	"plus and minus change the size, and Escape returns to the maze from the settings."
+
+// Called on every keydown in the document.
+func keyCallback(event js.Value) {
+	if event.Get("ctrlKey").Truthy() || event.Get("metaKey").Truthy() || event.Get("altKey").Truthy() {
+		return
+	}
+	key := event.Get("key").String()
+
+	// Leave keys alone while the user is adjusting a setting, except
+	// for Escape, which brings them back to the maze.
+	switch event.Get("target").Get("tagName").String() {
+	case "INPUT", "SELECT", "TEXTAREA":
+		if key == "Escape" {
+			focusMaze()
+		}
+		return
+	}
+
+	if d, ok := moveKeys[key]; ok && currentGame != nil {
+		event.Call("preventDefault")
+		currentGame.play(d)
+		return
+	}
+
+	if fn, ok := shortcuts[key]; ok {
+		event.Call("preventDefault")
+		fn()
+	}
+}
+
+// Flip a checkbox and redraw the current maze to match.
+func toggle(id string) {
+	checkbox := js.Global().Get("document").Call("getElementById", id)
+	checkbox.Set("checked", !checkbox.Get("checked").Truthy())
+	if currentMaze == nil {
+		return
+	}
+
+	args, err := getArguments()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	renderMaze(currentMaze, currentSeed, args)
+	if args.play {
+		focusMaze()
+	}
+}
+
+// Grow or shrink both dimensions, and generate a new maze to match.
+func resize(delta int) {
+	document := js.Global().Get("document")
+	for _, id := range []string{"mazeHeight", "mazeWidth"} {
+		value, err := strconv.Atoi(document.Call("getElementById", id).Get("value").String())
+		if err != nil {
+			continue
+		}
+		value += delta
+		if value < 2 {
+			value = 2
+		}
+		if value > maxDimension {
+			value = maxDimension
+		}
+		setDimension(id, value)
+	}
+	generateCallback()
+}
+
+// Move keyboard focus to the maze.
+func focusMaze() {
+	js.Global().Get("document").Call("getElementById", "mazeContainer").Call("focus")
+}
+
+// Call fn with every keydown event in the document, until we're
+// disposed of.
+func listenKeys(fn func(js.Value)) {
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn(args[0])
+		return nil
+	})
+
+	document := js.Global().Get("document")
+	document.Call("addEventListener", "keydown", cb)
+	attached(func() {
+		document.Call("removeEventListener", "keydown", cb)
+		cb.Release()
+	})
+}
@@ -0,0 +1,136 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"unicode"
+)
+
+// The label format used if none is given. Formats may include any of
+// {height}, {width}, {seed} (in hex), {length} (of the solution, in
+// cells), {difficulty} (see difficulty), {name} (see mazeName), and
+// {algorithm}.
+const defaultLabelFormat = "{height}x{width} {seed}"
+
+// Fill in the fields of a label format for the given maze. The
+// solution is only worked out if the format needs it.
+func formatLabel(format string, m *maze, seed int64) string {
+	if format == "" {
+		format = defaultLabelFormat
+	}
+
+	fields := []string{
+		"{height}", strconv.Itoa(m.height),
+		"{width}", strconv.Itoa(m.width),
+		"{seed}", fmt.Sprintf("%x", seed),
+		"{algorithm}", m.algorithm,
+		"{name}", mazeName(seed),
+	}
+	if strings.Contains(format, "{length}") || strings.Contains(format, "{difficulty}") {
+		path := m.solution()
+		fields = append(fields,
+			"{length}", strconv.Itoa(len(path)),
+			"{difficulty}", strconv.Itoa(m.difficulty(path)),
+		)
+	}
+	return strings.NewReplacer(fields...).Replace(format)
+}
+
+// The label the settings ask for on the given maze, if any.
+func (args arguments) labelFor(m *maze, seed int64) string {
+	if !args.label {
+		return ""
+	}
+	label := formatLabel(args.labelFormat, m, seed)
+	if args.caption != "" {
+		label = args.caption + " " + label
+	}
+	return label
+}
+
+// How to set the label. Rotating it 90 or 270 degrees runs it down the
+// right edge or up the left edge of the maze, for books bound in
+// portrait that print mazes sideways. Direction is "ltr", "rtl", or
+// anything else to work it out from the text.
+type labelStyle struct {
+	rotation  int
+	direction string
+}
+
+// The style of the current maze's label.
+var currentLabelStyle labelStyle
+
+const labelFontSize = "10px"
+
+// The font family labels are set in; see loadFont.
+var labelFont = "serif"
+
+// Whether text should be set right to left: that is, whether its first
+// strongly directional letter is from a right-to-left script.
+func textDirection(text string) string {
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko):
+			return "rtl"
+		case unicode.IsLetter(r):
+			return "ltr"
+		}
+	}
+	return "ltr"
+}
+
+// Draw the label in the border of a maze image of the given size, on
+// the given canvas context, in the given style.
+func drawLabel(ctx js.Value, text string, width, height int, style labelStyle) {
+	if text == "" {
+		return
+	}
+
+	direction := style.direction
+	if direction != "ltr" && direction != "rtl" {
+		direction = textDirection(text)
+	}
+	rtl := direction == "rtl"
+
+	// Work out where the label starts reading from and which way it
+	// reads. Text always sits just outside the maze, with its glyphs
+	// in the border.
+	x, y, angle := float64(border), float64(border-1), 0.0
+	switch style.rotation {
+	case 90:
+		x, y, angle = float64(width-border+1), float64(border), math.Pi/2
+		if rtl {
+			y = float64(height - border)
+		}
+	case 270:
+		x, y, angle = float64(border-1), float64(height-border), -math.Pi/2
+		if rtl {
+			y = float64(border)
+		}
+	default:
+		if rtl {
+			x = float64(width - border)
+		}
+	}
+
+	align := "left"
+	if rtl {
+		align = "right"
+	}
+
+	ctx.Call("save")
+	ctx.Call("translate", x, y)
+	ctx.Call("rotate", angle)
+	ctx.Set("direction", direction)
+	ctx.Set("textAlign", align)
+	ctx.Set("fillStyle", "black")
+	ctx.Set("font", labelFontSize+" "+labelFont)
+	ctx.Call("fillText", text, 0, 0)
+	ctx.Call("restore")
+}
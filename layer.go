@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+)
+
+// Draw the solution path alone onto a transparent image the same size
+// as the maze's, so that pages can stack it over the puzzle (or not).
+func (m *maze) solutionLayer(path []position) *image.RGBA {
+	defer tr(ace("drawing solution layer"))
+
+	width, height := m.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	m.drawPath(img, path)
+	return img
+}
+
+// Encode an image as a PNG.
+func encodePNG(img image.Image) ([]byte, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
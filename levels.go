@@ -0,0 +1,125 @@
+package main
+
+import (
+	"image/color"
+	"image/draw"
+	"math/rand"
+)
+
+// A maze can be stacked into floors, each a grid of square cells like
+// a square maze's, with stairs up and down between cells in the same
+// place on floors next to each other. The entrance is on the first
+// floor and the exit on the last, so every solution climbs through
+// them all. Floors are drawn side by side, first to last from left to
+// right, and a stair is a mark in each cell it joins: a caret for one
+// going up, a vee for one going down.
+
+const maxLevels = 4 // Most floors in a stacked maze
+
+// Grids whose cells are joined by stairs as well as by gaps in walls.
+// A stair isn't a wall: it's drawn as a mark in the cell when it's
+// open, and not at all when it's closed, and solutions take it without
+// a line from one cell to the other.
+type stairs interface {
+	isStair(side int) bool
+	drawStair(img draw.Image, i, side int, col color.Color)
+}
+
+// Floors of square cells. A cell's sides are the directions out of
+// it: north, south, east, west, up, and down.
+type levelGrid struct {
+	levels, rows, columns int
+}
+
+// The floor a cell is on, and where it is on that floor.
+func (g levelGrid) locate(i int) (level int, p position) {
+	floor := g.rows * g.columns
+	return i / floor, position{x: i % floor % g.columns, y: i % floor / g.columns}
+}
+
+func (g levelGrid) neighbors() [][]int {
+	floor := g.rows * g.columns
+	adj := make([][]int, g.levels*floor)
+	for i := range adj {
+		level, p := g.locate(i)
+		adj[i] = []int{-1, -1, -1, -1, -1, -1}
+		if p.y > 0 {
+			adj[i][north] = i - g.columns
+		}
+		if p.y < g.rows-1 {
+			adj[i][south] = i + g.columns
+		}
+		if p.x < g.columns-1 {
+			adj[i][east] = i + 1
+		}
+		if p.x > 0 {
+			adj[i][west] = i - 1
+		}
+		if level < g.levels-1 {
+			adj[i][up] = i + floor
+		}
+		if level > 0 {
+			adj[i][down] = i - floor
+		}
+	}
+	return adj
+}
+
+// The entrance is on the north side of a cell in the top row of the
+// first floor, and the exit on the south side of one in the bottom row
+// of the last.
+func (g levelGrid) ends(rng *rand.Rand) (start, startSide, finish, finishSide int) {
+	floor := g.rows * g.columns
+	return rng.Intn(g.columns), int(north), (g.levels-1)*floor + (g.rows-1)*g.columns + rng.Intn(g.columns), int(south)
+}
+
+// The width of a floor, and the gap after it (in pixels).
+func (g levelGrid) floorWidth() int {
+	return g.columns*cellWidth + border
+}
+
+func (g levelGrid) imageSize() (width, height int) {
+	return g.levels*g.floorWidth() + border + 1, g.rows*cellWidth + border*2 + 1
+}
+
+// The top left corner of a cell.
+func (g levelGrid) corner(i int) (x, y int) {
+	level, p := g.locate(i)
+	return border + level*g.floorWidth() + p.x*cellWidth, border + p.y*cellWidth
+}
+
+func (g levelGrid) center(i int) (x, y float64) {
+	cx, cy := g.corner(i)
+	return float64(cx + halfCellWidth), float64(cy + halfCellWidth)
+}
+
+func (g levelGrid) drawWall(img draw.Image, i, side int) {
+	x, y := g.corner(i)
+	switch direction(side) {
+	case north:
+		hLine(img, x, y, x+cellWidth, wallColor)
+	case south:
+		hLine(img, x, y+cellWidth, x+cellWidth, wallColor)
+	case west:
+		vLine(img, x, y, y+cellWidth, wallColor)
+	case east:
+		vLine(img, x+cellWidth, y, y+cellWidth, wallColor)
+	}
+}
+
+func (g levelGrid) isStair(side int) bool {
+	return direction(side) == up || direction(side) == down
+}
+
+// Stairs up are marked in the top half of the cell, and stairs down in
+// the bottom half, so a cell with both shows both.
+func (g levelGrid) drawStair(img draw.Image, i, side int, col color.Color) {
+	x, y := g.center(i)
+	reach := float64(cellWidth) / 4
+	tip, ends := y-reach, y-1
+	if direction(side) == down {
+		tip, ends = y+reach, y+1
+	}
+	line(img, x-reach, ends, x, tip, col)
+	line(img, x, tip, x+reach, ends, col)
+}
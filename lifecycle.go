@@ -0,0 +1,183 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// Nothing touches the page until it calls MazeGen.init, naming the
+// canvas to draw on, and MazeGen.dispose undoes all of it, so that
+// single-page apps can mount and unmount the generator like any
+// other widget.
+//
+// MazeGen.shutdown goes further, for pages that swap in a new WASM
+// module: it disposes, then removes our JS-facing objects, releases
+// every callback, and lets main return, so nothing of ours is left.
+
+var (
+	noCanvas        = errors.New("no such canvas")
+	alreadyAttached = errors.New("already initialized")
+)
+
+// Closed to shut down.
+var shutdown = make(chan struct{})
+
+// Our JS-facing objects, by global name, and their callbacks.
+var (
+	published     []string
+	publishedFunc []js.Func
+)
+
+// Build a JS-facing object and make it global, until we shut down.
+func publish(name string, build func() (js.Value, []js.Func)) {
+	object, funcs := build()
+	js.Global().Set(name, object)
+	published = append(published, name)
+	publishedFunc = append(publishedFunc, funcs...)
+}
+
+// Remove everything publish made global, and release its callbacks.
+func unpublish() {
+	for _, name := range published {
+		js.Global().Delete(name)
+	}
+	for _, f := range publishedFunc {
+		f.Release()
+	}
+	published, publishedFunc = nil, nil
+}
+
+// The canvas mazes are drawn on.
+var targetCanvas js.Value = js.Undefined()
+
+// Functions undoing everything init did, in the order it did them.
+var detachers []func()
+
+// Remember how to undo something init did.
+func attached(fn func()) {
+	detachers = append(detachers, fn)
+}
+
+// Attach to the page: draw on the given canvas, and listen to the
+// settings form and the rest of the page. Options are:
+//
+//	reserveMemory  set aside the largest frame buffer now (see memory.go)
+func attach(canvas, options js.Value) error {
+	if detachers != nil {
+		return alreadyAttached
+	}
+	if canvas.Type() == js.TypeString {
+		canvas = js.Global().Get("document").Call("querySelector", canvas)
+	}
+	if canvas.Type() != js.TypeObject {
+		return noCanvas
+	}
+	targetCanvas = canvas
+
+	if options.Type() == js.TypeObject && options.Get("reserveMemory").Truthy() {
+		reserveMemory()
+	}
+
+	listen("generateButton", "click", generateCallback)
+	listen("compareButton", "click", compareCallback)
+	listen("diffButton", "click", diffCallback)
+	listen("explainButton", "click", explainCallback)
+	listen("exportChainButton", "click", exportChainCallback)
+	listen("exportBatchButton", "click", exportBatchCallback)
+	listen("exportSettingsButton", "click", exportSettingsCallback)
+	listen("exportSVGButton", "click", exportSVGCallback)
+	listen("exportHPGLButton", "click", exportHPGLCallback)
+	listen("exportBRFButton", "click", exportBRFCallback)
+	listen("exportTactileButton", "click", exportTactileCallback)
+	listen("exportThermalButton", "click", exportThermalCallback)
+	listen("exportPBMButton", "click", exportPBMCallback)
+	listen("exportAPNGButton", "click", exportAPNGCallback)
+	listen("exportLayerButton", "click", exportLayerCallback)
+	listen("exportStitchButton", "click", exportStitchCallback)
+	listen("exportBricksButton", "click", exportBricksCallback)
+	listen("exportCuttingButton", "click", exportCuttingCallback)
+	listen("exportOpenSCADButton", "click", exportOpenSCADCallback)
+	listen("exportPosterButton", "click", exportPosterCallback)
+	listen("exportHTMLButton", "click", exportHTMLCallback)
+	listen("exportXLSXButton", "click", exportXLSXCallback)
+	listen("exportPNGButton", "click", exportPNGCallback)
+	listen("exportPDFButton", "click", exportPDFCallback)
+	observeResize("mazeContainer", resizeCallback)
+	listenInput("settingsForm", liveCallback)
+	listenKeys(keyCallback)
+	listenCells(targetCanvas)
+	if glCanvas := element("glCanvas"); !glCanvas.IsNull() {
+		listenCells(glCanvas)
+	}
+	listenWindow("beforeprint", func() { printCallback(true) })
+	listenWindow("afterprint", func() { printCallback(false) })
+
+	return nil
+}
+
+// Detach from the page, stopping anything in progress, and let go of
+// the current maze and its buffers.
+func detach() {
+	stopAnimation()
+	regenerate.cancel()
+	regenerateLive.cancel()
+
+	for i := len(detachers) - 1; i >= 0; i-- {
+		detachers[i]()
+	}
+	detachers = nil
+
+	currentMaze, currentSolution, currentLabel, currentSeed, currentID = nil, nil, "", 0, ""
+	stopGame()
+	currentWorld = nil
+	history, historyHook = nil, js.Undefined()
+	cellHook, hovering = js.Undefined(), false
+	explainHook = js.Undefined()
+	moveHook = js.Undefined()
+	currentMask = nil
+	frameBuffer, frameBufferMaze = nil, nil
+	reservedPixels = nil
+	glView = nil
+	currentView, viewSteps = nil, nil
+	targetCanvas = js.Undefined()
+}
+
+func initJS(this js.Value, args []js.Value) interface{} {
+	canvas, options := js.Undefined(), js.Undefined()
+	if len(args) > 0 {
+		canvas = args[0]
+	}
+	if len(args) > 1 {
+		options = args[1]
+	}
+	if err := attach(canvas, options); err != nil {
+		return err.Error()
+	}
+	return js.Null()
+}
+
+func disposeJS(this js.Value, args []js.Value) interface{} {
+	detach()
+	return nil
+}
+
+// Dispose, stop using the page's JS functions, and let main finish
+// the job once this callback has returned. Calling it more than once
+// does nothing.
+func shutdownJS(this js.Value, args []js.Value) interface{} {
+	select {
+	case <-shutdown:
+		return nil
+	default:
+	}
+
+	detach()
+	regenerate.release()
+	regenerateLive.release()
+	putMaze, offerDownload = js.Undefined(), js.Undefined()
+	close(shutdown)
+	return nil
+}
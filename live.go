@@ -0,0 +1,53 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+const liveDelay = 300 // Milliseconds to wait after the last change before regenerating
+
+// The inputs that trigger regeneration in live mode.
+var liveInputs = map[string]bool{
+	"mazeHeight": true,
+	"mazeWidth":  true,
+	"randomSeed": true,
+}
+
+var regenerateLive = newDebouncer(generateCallback)
+
+// Called on every input event in the settings form. In live mode, a
+// change to the maze's parameters cancels whatever is running and
+// regenerates once the user stops fiddling.
+func liveCallback(event js.Value) {
+	if !pageSettings.checked("liveMode") {
+		return
+	}
+	if !liveInputs[event.Get("target").Get("id").String()] {
+		return
+	}
+
+	stopAnimation()
+	regenerateLive.trigger(liveDelay)
+}
+
+// Call fn with every input event in the element with the given ID,
+// until we're disposed of.
+func listenInput(id string, fn func(js.Value)) {
+	element := element(id)
+	if element.IsNull() {
+		return
+	}
+
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn(args[0])
+		return nil
+	})
+	element.Call("addEventListener", "input", cb)
+	attached(func() {
+		element.Call("removeEventListener", "input", cb)
+		cb.Release()
+	})
+}
@@ -1,422 +1,634 @@
+//go:build js
+// +build js
+
 package main
 
 import (
-	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
 	"math/rand"
 	"strconv"
 	"syscall/js"
-	"time"
 	"unsafe"
 )
 
-// Simple timing functions, put
-// tr(ace(message))
-// at the top of a timed function.
-func ace(message string) (string, time.Time) {
-	return message, time.Now()
-}
-
-func tr(message string, start time.Time) {
-	fmt.Printf("%v: %v\n", message, time.Since(start))
-}
+// The frame buffer storing our image. This is an *image.RGBA unless
+// we've been asked to save memory; see pixelformat.go.
+var frameBuffer draw.Image = nil
+
+// The maze last drawn into the frame buffer by draw. The other
+// renderers draw straight onto their canvases.
+var frameBufferMaze *maze = nil
+
+// The most recently generated maze and, if it was requested, its
+// solution. These are what the vector exporters work from.
+var (
+	currentMaze     *maze      = nil
+	currentSolution []position = nil
+	currentLabel    string     = ""
+	currentSeed     int64      = 0
+)
 
-// The frame buffer storing our image.
-var frameBuffer *image.RGBA = nil
+// Draw the maze to an image.
+func (m *maze) draw() draw.Image {
+	defer tr(ace("drawing maze"))
 
-// Mazes are simple structures.
-type maze struct {
-	start, finish position
-	height, width int
-	cells         []cell
-	rng           *rand.Rand
-}
+	width, height := m.imageSize()
+	bounds := image.Rect(0, 0, width, height)
+	if frameBuffer == nil || frameBuffer.Bounds() != bounds || !frameBufferMatches() {
+		frameBuffer = newFrameBuffer(bounds)
+	}
+	m.drawOnto(frameBuffer)
+	frameBufferMaze = m
+	if showHeatmap {
+		m.drawHeatmap(frameBuffer, m.distances(m.start))
+	}
+	if showJunctions {
+		m.drawJunctions(frameBuffer)
+	}
 
-func (m *maze) at(p position) *cell {
-	return &m.cells[p.y*m.width+p.x]
+	return frameBuffer
 }
 
-const (
-	maxDimension  = 200                    // Maximum number of cells in height and/or width
-	border        = 40                     // Border (in pixels) around the maze
-	cellWidth     = 12                     // Width/height (in pixels) of a single cell
-	halfCellWidth = cellWidth / 2          // Used to find the midpoint of a cell
-	minImageWidth = cellWidth*4 + border*2 // Minimum width of generated image (in pixels)
-)
+// We import a function called putMaze, which is written in JavaScript.
+// TinyGo makes this slightly easier, but this really isn't too bad:
+var putMaze js.Value = js.Global().Get("putMaze")
 
-// Directions, and displacements to move in a given direction.
-type direction int
+// Likewise offerDownload, which hands a file to the user.
+var offerDownload js.Value = js.Global().Get("offerDownload")
 
-const (
-	north direction = iota
-	south
-	east
-	west
-)
+// Call fn when the element with the given ID fires the given event,
+// until we're disposed of. The event's default action is suppressed.
+// Pages needn't have every element we listen to.
+func listen(id, event string, fn func()) {
+	element := element(id)
+	if element.IsNull() {
+		return
+	}
 
-var outOfBounds = errors.New("out of bounds")
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn()
+		args[0].Call("preventDefault")
+		return nil
+	})
 
-func (d direction) translate(p position, m *maze) (position, error) {
-	switch d {
-	case north:
-		if p.y > 0 {
-			return position{x: p.x, y: p.y - 1}, nil
-		}
-	case south:
-		if p.y < m.height-1 {
-			return position{x: p.x, y: p.y + 1}, nil
-		}
-	case west:
-		if p.x > 0 {
-			return position{x: p.x - 1, y: p.y}, nil
-		}
-	case east:
-		if p.x < m.width-1 {
-			return position{x: p.x + 1, y: p.y}, nil
-		}
-	}
-	return p, outOfBounds
+	element.Call("addEventListener", event, cb)
+	attached(func() {
+		element.Call("removeEventListener", event, cb)
+		cb.Release()
+	})
 }
 
-func (d direction) opposite() direction {
-	return map[direction]direction{
-		north: south,
-		south: north,
-		east:  west,
-		west:  east,
-	}[d]
+func main() {
+	publish("mazeAnimation", animationControls)
+	publish("mazeGL", glControls)
+	publish("mazeGame", gameControls)
+	publish("mazeFonts", fontControls)
+	publish("mazeAnalysis", analysisControls)
+	publish("mazeBatch", batchControls)
+	publish("mazeVerify", verifyControls)
+	publish("mazePredict", predictControls)
+	publish("mazeDebug", debugControls)
+	publish("MazeGen", apiControls)
+
+	// Wait until we're shut down. We mustn't fall off the end of main
+	// before then, or the Go runtime exits and takes our callbacks and
+	// frame buffer with it.
+	<-shutdown
+	unpublish()
 }
 
-// A single cell.
-type cell struct {
-	openings [4]bool // Whether a given wall is open.
+// The actual function called to generate mazes.
+func generateCallback() {
+	generateFrom(pageSettings)
 }
 
-// Build a new maze with the given height and width.
-// Randomness is taken from the given RNG.
-// oppositeStart means to place start/end at opposing corners.
-func newMaze(height, width int, rng *rand.Rand, oppositeStart bool) *maze {
-	if width < 2 || height < 2 || rng == nil {
-		panic("invalid call to newMaze")
-	}
+// Generate and draw a maze with the settings from the given source, and
+// make it the current maze. Returns the maze, or nil if the settings
+// won't do.
+func generateFrom(s settingSource) *maze {
+	defer tr(ace("total time"))
 
-	start := position{rng.Intn(width), 0}
-	end := position{rng.Intn(width), height - 1}
-	if oppositeStart {
-		start = position{0, 0}
-		end = position{width - 1, height - 1}
+	useTheme(themeFrom(s))
+	if fitMode() == fitFill {
+		height, width := fillDimensions()
+		setDimension("mazeHeight", height)
+		setDimension("mazeWidth", width)
 	}
-	return &maze{
-		start:  start,
-		finish: end,
-		height: height,
-		width:  width,
-		cells:  make([]cell, height*width),
-		rng:    rng,
+	args, err := argumentsFrom(s)
+	if err != nil || args.height < 2 || args.width < 2 || args.height > maxDimension || args.width > maxDimension {
+		fmt.Printf("Error: %s\n", err)
+		return nil
 	}
-}
 
-// Position is simply x/y coordinates.
-type position struct {
-	x, y int
-}
-
-// We use a stack of positions when generating and solving
-// the maze. This avoids using the call stack. Go has a very
-// deep call stack on most targets, but I'm not comfortable
-// asking WASM to give us a ~1500-level stack.
-type stack struct {
-	stack []position
-}
-
-func (s *stack) push(p position) {
-	s.stack = append(s.stack, p)
-}
-
-func (s stack) peek() position {
-	if len(s.stack) == 0 {
-		panic("stack underflow")
+	seed := args.seed
+	if seed == 0 {
+		seed = randomSeed()
 	}
-	return s.stack[len(s.stack)-1]
-}
 
-func (s *stack) pop() position {
-	p := s.peek()
-	s.stack = s.stack[:len(s.stack)-1]
-	return p
-}
+	switch args.topology {
+	case hexTopology, thetaTopology, levelsTopology:
+		if !drawnWhole(int(args.height), int(args.width)) {
+			fmt.Printf("Error: shaped mazes are at most %d cells on a side\n", maxDrawnCells())
+			return nil
+		}
+		renderShaped(args.topology, seed, args)
+		return nil
+	}
 
-func (s stack) empty() bool {
-	return len(s.stack) == 0
-}
+	for _, g := range generators[1:] {
+		if currentMask != nil && g.name == args.algorithm {
+			fmt.Printf("Error: %s\n", maskedAlgorithm)
+			return nil
+		}
+	}
+	m := newMaze(int(args.height), int(args.width), rand.New(rand.NewSource(seed)), args.oppositeStart)
+	if currentMask != nil {
+		m.applyMask(currentMask.sample(m.height, m.width))
+	}
+	if args.animateGeneration {
+		m.generateRecorded(args.algorithm)
+	} else {
+		m.generateWith(args.algorithm)
+	}
+	if args.decoys > 0 {
+		m.addDecoys(args.decoys)
+	}
+	if args.braid > 0 {
+		m.braid(float64(args.braid) / 100)
+	}
+	if args.targetSolutions > 1 {
+		reportSolutions(m.addSolutions(args.targetSolutions))
+	}
+	currentWorld = nil
+	if args.rooms > 1 {
+		currentWorld = newWorld(m, args.oppositeStart, args.rooms)
+	}
 
-func (s stack) len() int {
-	return len(s.stack)
+	renderMaze(m, seed, args)
+	currentID = m.id(seed)
+	currentShaping.decoys, currentShaping.solutions, currentShaping.braid = args.decoys, args.targetSolutions, args.braid
+	remember(m, seed, args)
+	if args.play {
+		focusMaze()
+	}
+	return m
 }
 
-// We precompute all possible permutations of orders to try digging.
-// This speeds up maze generation by ~25% from shuffling the directions
-// on each iteration through the maze generation loop.
-var permutations = [][]direction{
-	[]direction{north, south, east, west},
-	[]direction{north, south, west, east},
-	[]direction{north, east, south, west},
-	[]direction{north, east, west, south},
-	[]direction{north, west, south, east},
-	[]direction{north, west, east, south},
-	[]direction{south, north, east, west},
-	[]direction{south, north, west, east},
-	[]direction{south, east, north, west},
-	[]direction{south, east, west, north},
-	[]direction{south, west, north, east},
-	[]direction{south, west, east, north},
-	[]direction{east, north, south, west},
-	[]direction{east, north, west, south},
-	[]direction{east, south, north, west},
-	[]direction{east, south, west, north},
-	[]direction{east, west, north, south},
-	[]direction{east, west, south, north},
-	[]direction{west, north, south, east},
-	[]direction{west, north, east, south},
-	[]direction{west, south, north, east},
-	[]direction{west, south, east, north},
-	[]direction{west, east, north, south},
-	[]direction{west, east, south, north},
-}
+// Render a maze, generated from the given seed, with the given
+// settings, and make it the current maze.
+func renderMaze(m *maze, seed int64, args arguments) {
+	stopAnimation()
+	if currentGame != nil && currentGame.m == m && currentSolution == nil && args.solution {
+		currentGame.report(true)
+	}
+	stopGame()
 
-type visitedMap map[position]bool
+	labelText := args.labelFor(m, seed)
+	currentLabelStyle = labelStyle{args.labelRotation, args.labelDirection}
+	pixelFormat = args.pixelFormat
+	showJunctions = args.junctions
+	showHeatmap = args.heatmap
 
-func (m visitedMap) contains(p position) (ok bool) {
-	_, ok = m[p]
-	return
-}
+	currentMaze, currentSolution, currentLabel, currentSeed = m, nil, labelText, seed
+	if args.solution {
+		currentSolution = m.solution()
+	}
 
-func (m *maze) carve(p position, d direction) {
-	m.at(p).openings[d] = true
-	if np, err := d.translate(p, m); err == nil {
-		m.at(np).openings[d.opposite()] = true
+	currentView, viewSteps = nil, nil
+	if m.viewOnly() {
+		renderView(m)
+		return
 	}
-}
 
-func (m *maze) generate() {
-	defer tr(ace("generating maze"))
-
-	stack := stack{[]position{m.start}}
-	visited := make(visitedMap)
-	for !stack.empty() {
-		found := false
-		p := stack.peek()
-		dirs := permutations[m.rng.Intn(len(permutations))]
-		for _, dir := range dirs {
-			np, err := dir.translate(p, m)
-			if err == nil && !visited.contains(np) {
-				m.carve(p, dir)
-				visited[np] = true
-				stack.push(np)
-				found = true
-				break
-			}
+	// Printing always uses the raster renderer, which can draw the
+	// print style, as do the flood fill, dead-end filling, and watching
+	// generation.
+	growing := args.animateGeneration && m.carvings != nil
+	rasterOnly := printing || args.floodFill || args.deadEndFill || growing
+	if args.renderer == webGLRenderer && !rasterOnly {
+		err := renderGL(m, currentSolution, args.animateSolution, args.solutionDuration)
+		if err == nil {
+			return
 		}
+		fmt.Printf("Error: %s, falling back to raster\n", err)
+	}
+	showGLCanvas(false)
 
-		if !found {
-			stack.pop()
-		}
+	if r, ok := renderers[args.renderer]; ok && !rasterOnly {
+		resetCanvasStyle()
+		r(m, currentSolution, labelText)
+		return
 	}
 
-	m.at(m.start).openings[north] = true
-	m.at(m.finish).openings[south] = true
-}
+	// Fitting to the container means scaling, which only the canvas
+	// renderer can do without blurring.
+	if fitMode() == fitScale && !rasterOnly {
+		renderCanvas(m, currentSolution, labelText, fitZoom(m), args.animateSolution, args.solutionDuration)
+		return
+	}
+	if fitMode() == fitFill && !rasterOnly {
+		renderCanvas(m, currentSolution, labelText, fillZoom(m), args.animateSolution, args.solutionDuration)
+		return
+	}
 
-// Solve via depth-first search.
-// Use the same stack mechanism as the maze generator.
-func (m maze) solve() []position {
-	defer tr(ace("solving maze"))
+	if args.renderer == canvasRenderer && !rasterOnly {
+		renderCanvas(m, currentSolution, labelText, 1, args.animateSolution, args.solutionDuration)
+		return
+	}
+	resetCanvasStyle()
 
-	stack := stack{[]position{m.start}}
-	visited := make(visitedMap)
-	visited[m.start] = true
+	if growing && !printing {
+		animate(m.growth(currentSolution, args.solutionDuration, func() {
+			dither(frameBuffer, args.ditherMethod)
+			export(labelText)
+		}))
+		return
+	}
+	if args.floodFill && !printing {
+		animate(m.flood(currentSolution, args.solutionDuration, func() {
+			dither(frameBuffer, args.ditherMethod)
+			export(labelText)
+		}))
+		return
+	}
+	if args.deadEndFill && !printing {
+		animate(m.deadEndAnimation(args.solutionDuration, func() {
+			dither(frameBuffer, args.ditherMethod)
+			export(labelText)
+		}))
+		return
+	}
 
-SEARCH:
-	for !stack.empty() {
-		if visited.contains(m.finish) {
-			return stack.stack
+	img := m.draw()
+	if currentSolution != nil && printing {
+		m.drawStyledPath(img, currentSolution, printStyle())
+	} else if currentSolution != nil {
+		if args.animateSolution {
+			path := currentSolution
+			animate(newAnimation(len(path)-1, args.solutionDuration,
+				func() { img = m.draw() },
+				func(i int) { m.drawSegment(img, path[i], path[i+1]) },
+				func() {
+					dither(img, args.ditherMethod)
+					export(labelText)
+				},
+			))
+			return
 		}
-
-		pos := stack.peek()
-		for _, dir := range []direction{north, south, east, west} {
-			if np, err := dir.translate(pos, &m); err == nil && !visited.contains(np) && m.at(pos).openings[dir] {
-				visited[np] = true
-				stack.push(np)
-				continue SEARCH
-			}
+		if args.routes > 1 {
+			routes := m.routes(args.routes)
+			m.drawRoutes(img, routes)
+			reportRoutes(len(routes))
+		} else {
+			m.drawPath(img, currentSolution)
 		}
-		stack.pop()
 	}
+	if args.play {
+		currentGame = newGame(m)
+		currentGame.shiftEvery, currentGame.shiftWalls = args.shiftEvery, args.shiftWalls
+		if currentWorld != nil {
+			currentGame.enter(currentWorld)
+		}
+		currentGame.addEnemies(args.enemies, args.enemyKind, seed)
+		currentGame.addCheckpoints(args.checkpoints)
+		if args.challenge {
+			currentGame.limitMoves(args.spareMoves)
+		}
+		currentGame.drawPieces(img)
+		announce("New maze. Use the arrow keys to move. " + currentGame.describeExits())
+	}
+	dither(img, args.ditherMethod)
 
-	panic("maze has no solution")
+	export(labelText)
+	if currentGame != nil {
+		currentGame.follow()
+		currentGame.startEnemies()
+	}
 }
 
-// Draw the maze to an image.
-func (m *maze) draw() *image.RGBA {
-	defer tr(ace("drawing maze"))
-
-	width := m.width*cellWidth + border*2
-	if width < minImageWidth {
-		width = minImageWidth
+// Render the current maze again with the current settings, keeping
+// any game in progress going.
+func redrawCurrent() {
+	if currentMaze == nil {
+		return
 	}
 
-	bounds := image.Rect(0, 0, width, m.height*cellWidth+border*2)
-	if frameBuffer == nil || frameBuffer.Bounds() != bounds {
-		frameBuffer = image.NewRGBA(bounds)
+	args, err := getArguments()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
 	}
-	fill(frameBuffer, 0, m.height*cellWidth+border*2, 0, width, image.White)
 
-	for y := 0; y < m.height; y++ {
-		for x := 0; x < m.width; x++ {
-			m.drawCell(frameBuffer, x, y, m.at(position{x: x, y: y}))
-		}
+	game := currentGame
+	renderMaze(currentMaze, currentSeed, args)
+	if game != nil {
+		stopGame()
+		currentGame = game
+		game.redraw()
+		game.startEnemies()
 	}
+}
 
-	return frameBuffer
+// Whether there's a current maze to export. Exports are of the whole
+// maze, so mazes too big to draw whole aren't exported; building them
+// would take far too long, or far too much memory.
+func exportable() bool {
+	return currentMaze != nil && !currentMaze.viewOnly()
 }
 
-// Preallocate the red image; this is what we use as a
-// stamp to draw our solution if requested.
-var red = image.NewUniform(color.RGBA{255, 0, 0, 255})
-
-// Draw the solution path.
-// Note that we sort our origin and destination points
-// so that we don't have any gaps and it's a nice smooth
-// connected path.
-func (m *maze) drawPath(img *image.RGBA, path []position) {
-	defer tr(ace("drawing solution"))
-
-	prev := path[0]
-	for _, pos := range path[1:] {
-		if pos.x == prev.x {
-			first, last := prev, pos
-			if first.y > last.y {
-				first, last = last, first
-			}
-			vLine(img, first.x*cellWidth+border+halfCellWidth, first.y*cellWidth+border+halfCellWidth, last.y*cellWidth+border+halfCellWidth, red)
-		}
-		if pos.y == prev.y {
-			first, last := prev, pos
-			if first.x > last.x {
-				first, last = last, first
-			}
-			hLine(img, first.x*cellWidth+border+halfCellWidth, first.y*cellWidth+border+halfCellWidth, last.x*cellWidth+border+halfCellWidth, red)
-		}
-		prev = pos
+// Export the current maze as SVG.
+func exportSVGCallback() {
+	if !exportable() {
+		return
 	}
+	offerDownload.Invoke("maze.svg", "image/svg+xml", currentMaze.svgPage(currentSolution, printStyle(), pageSetupSetting(pageSettings)))
 }
 
-// Fill the image with a given color.
-func fill(img *image.RGBA, y0, y1, x0, x1 int, color color.Color) {
-	defer tr(ace("clearing image"))
-	draw.Draw(img, img.Bounds(), &image.Uniform{color}, image.Point{0, 0}, draw.Src)
+// Export the current maze as HPGL for pen plotters.
+func exportHPGLCallback() {
+	if !exportable() {
+		return
+	}
+	offerDownload.Invoke("maze.hpgl", "application/vnd.hp-hpgl", currentMaze.hpgl(currentSolution))
 }
 
-// Draw a horizontal line from p1 -> p2.
-func hLine(img *image.RGBA, x1, y, x2 int, col image.Image) {
-	draw.Draw(img, image.Rect(x1, y, x2+1, y+1), col, image.Point{0, 0}, draw.Over)
+// Export the current maze for braille embossers.
+func exportBRFCallback() {
+	if !exportable() {
+		return
+	}
+	offerDownload.Invoke("maze.brf", "text/plain", currentMaze.brf())
 }
 
-// Draw a vertical line from p1 -> p2.
-func vLine(img *image.RGBA, x, y1, y2 int, col image.Image) {
-	draw.Draw(img, image.Rect(x, y1, x+1, y2+1), col, image.Point{0, 0}, draw.Over)
+// Export the current maze as raised dots for swell paper.
+func exportTactileCallback() {
+	if !exportable() {
+		return
+	}
+	offerDownload.Invoke("maze-tactile.svg", "image/svg+xml", currentMaze.tactileSVG())
 }
 
-// Draw an individual cell.
-func (m *maze) drawCell(img *image.RGBA, x, y int, c *cell) {
-	if !c.openings[north] {
-		hLine(img, x*cellWidth+border, y*cellWidth+border, x*cellWidth+border+cellWidth, image.Black)
+// Render the current maze for the selected thermal paper width.
+func thermalBitmap() (*bitmap, error) {
+	document := js.Global().Get("document")
+	paper, err := strconv.Atoi(document.Call("getElementById", "thermalWidth").Get("value").String())
+	if err != nil {
+		return nil, err
 	}
+	return currentMaze.bitmap(thermalWidths[paper], currentSolution)
+}
 
-	if !c.openings[south] {
-		hLine(img, x*cellWidth+border, y*cellWidth+border+cellWidth, x*cellWidth+border+cellWidth, image.Black)
+// Export the current maze as ESC/POS commands for thermal printers.
+func exportThermalCallback() {
+	if !exportable() {
+		return
 	}
+	img, err := thermalBitmap()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("maze.bin", "application/octet-stream", bytesToJS(img.escpos()))
+}
 
-	if !c.openings[west] {
-		vLine(img, x*cellWidth+border, y*cellWidth+border, y*cellWidth+border+cellWidth, image.Black)
+// Export the current maze as a brick building plan.
+func exportBricksCallback() {
+	if !exportable() {
+		return
 	}
+	offerDownload.Invoke("maze-bricks.svg", "image/svg+xml", currentMaze.bricksSVG())
+}
 
-	if !c.openings[east] {
-		vLine(img, x*cellWidth+border+cellWidth, y*cellWidth+border, y*cellWidth+border+cellWidth, image.Black)
+// Export the current maze as an OpenSCAD script.
+func exportOpenSCADCallback() {
+	if !exportable() {
+		return
 	}
+	offerDownload.Invoke("maze.scad", "application/x-openscad", currentMaze.openSCAD())
 }
 
-// We import a function called putMaze, which is written in JavaScript.
-// TinyGo makes this slightly easier, but this really isn't too bad:
-var putMaze js.Value = js.Global().Get("putMaze")
+// Export the current maze as an HTML snippet, with its solution
+// hidden in it.
+func exportHTMLCallback() {
+	if !exportable() {
+		return
+	}
+	offerDownload.Invoke("maze.html", "text/html", currentMaze.htmlGrid(currentMaze.solution()))
+}
 
-func main() {
-	generateCb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		generateCallback()
-		args[0].Call("preventDefault")
-		return nil
-	})
-	defer generateCb.Release()
+// Export the current maze as a spreadsheet.
+func exportXLSXCallback() {
+	if !exportable() {
+		return
+	}
+	data, err := currentMaze.xlsx()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("maze.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", bytesToJS(data))
+}
 
-	js.Global().Get("document").
-		Call("getElementById", "generateButton").
-		Call("addEventListener", "click", generateCb)
+// Export the current maze as a PDF, with its solution on a layer, and
+// print marks if asked.
+func exportPDFCallback() {
+	if !exportable() {
+		return
+	}
+	pdf := currentMaze.pdf(currentMaze.solution(), currentLabel, printMarksSetting(pageSettings), pageSetupSetting(pageSettings))
+	offerDownload.Invoke("maze.pdf", "application/pdf", bytesToJS(pdf))
+}
 
-	// spin a while...spin FOREVER
-	// we do this so that we don't fall off the end of main and collect
-	// garbage, which could move our framebuffer pointer or do other
-	// breaking things
-	select {}
+// Read the PDF print marks settings.
+func printMarksSetting(s settingSource) printMarks {
+	marks := printMarks{
+		enabled: s.checked("printMarks"),
+		bleed:   s.number("bleed") * pointsPerMM,
+	}
+	if !(marks.bleed >= 0) || marks.bleed > slugSize/2 {
+		marks.bleed = 0
+	}
+	return marks
 }
 
-// The actual function called to generate mazes.
-func generateCallback() {
-	defer tr(ace("total time"))
+// Read how to lay vector output out on the page. Unknown paper fits
+// the page to the maze.
+func pageSetupSetting(s settingSource) pageSetup {
+	page := pageSetup{
+		paper:     s.value("printPaper"),
+		lineWidth: s.number("lineWidth"),
+	}
+	if !(page.lineWidth > 0) {
+		page.lineWidth = 0
+	}
+	return page
+}
 
-	height, width, solution, label, oppositeStart, seed, err := getArguments()
-	if err != nil || height < 2 || width < 2 || height > maxDimension || width > maxDimension {
+// Export the current maze as a 1-bit PBM image at thermal printer width.
+func exportPBMCallback() {
+	if !exportable() {
+		return
+	}
+	img, err := thermalBitmap()
+	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 		return
 	}
+	offerDownload.Invoke("maze.pbm", "image/x-portable-bitmap", bytesToJS(img.pbm()))
+}
 
-	if seed == 0 {
-		seed = time.Now().UnixNano()
+// Export the current maze's solution being drawn as an animated PNG.
+// The solution is exported whether or not it's being shown.
+func exportAPNGCallback() {
+	if !exportable() {
+		return
+	}
+	path := currentSolution
+	if path == nil {
+		path = currentMaze.solution()
 	}
 
-	m := newMaze(int(height), int(width), rand.New(rand.NewSource(seed)), oppositeStart)
-	m.generate()
+	duration := js.Global().Get("document").Call("getElementById", "solutionDuration").Get("valueAsNumber").Float()
+	data, err := currentMaze.solutionAPNG(path, duration)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("maze.png", "image/apng", bytesToJS(data))
+}
 
-	img := m.draw()
-	if solution {
-		m.drawPath(img, m.solve())
+// Export the current maze's solution as a transparent PNG layer.
+// The solution is exported whether or not it's being shown.
+func exportLayerCallback() {
+	if !exportable() {
+		return
+	}
+	path := currentSolution
+	if path == nil {
+		path = currentMaze.solution()
 	}
 
-	labelText := ""
-	if label {
-		labelText = fmt.Sprintf("%dx%d %x", m.height, m.width, seed)
+	data, err := encodePNG(currentMaze.solutionLayer(path))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
 	}
-	export(labelText)
+	offerDownload.Invoke("maze-solution.png", "image/png", bytesToJS(data))
+}
+
+// Copy bytes out of linear memory into a new Uint8Array.
+func bytesToJS(b []byte) js.Value {
+	array := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(array, b)
+	return array
+}
+
+// Parameters for generating a maze, as gathered from JS land.
+type arguments struct {
+	height, width     int64
+	levels            int // Floors, for stacked mazes
+	algorithm         string
+	topology          string
+	solution, label   bool
+	caption           string
+	labelFormat       string
+	labelRotation     int
+	labelDirection    string
+	oppositeStart     bool
+	ditherMethod      string
+	animateSolution   bool
+	animateGeneration bool
+	solutionDuration  float64 // In milliseconds
+	routes            int
+	targetSolutions   int
+	decoys            int
+	braid             int // Percentage of dead ends to knock through
+	floodFill         bool
+	deadEndFill       bool
+	junctions         bool
+	heatmap           bool
+	renderer          string
+	play              bool
+	shiftEvery        int
+	shiftWalls        int
+	enemies           int
+	enemyKind         string
+	stages            int
+	checkpoints       int
+	challenge         bool
+	spareMoves        int
+	rooms             int
+	pixelFormat       string
+	seed              int64
 }
 
 // Grab our parameters from JS land.
-func getArguments() (height, width int64, solution, label, oppositeStart bool, seed int64, err error) {
-	document := js.Global().Get("document")
+func getArguments() (args arguments, err error) {
+	return argumentsFrom(pageSettings)
+}
+
+// Grab our parameters from the given source. Numbers that don't parse
+// are an error naming the first such setting.
+func argumentsFrom(s settingSource) (args arguments, err error) {
+	integer := func(id string, bits int) int64 {
+		n, e := strconv.ParseInt(s.value(id), 10, bits)
+		if e != nil && err == nil {
+			err = fmt.Errorf("%s: %w", id, badNumber)
+		}
+		return n
+	}
+	count := func(id string) int {
+		return int(integer(id, 0))
+	}
 
-	height, err = strconv.ParseInt(document.Call("getElementById", "mazeHeight").Get("value").String(), 10, 16)
-	width, err = strconv.ParseInt(document.Call("getElementById", "mazeWidth").Get("value").String(), 10, 16)
-	seed, err = strconv.ParseInt(document.Call("getElementById", "randomSeed").Get("value").String(), 10, 64)
-	solution = document.Call("getElementById", "showSolution").Get("checked").Truthy()
-	label = document.Call("getElementById", "labelMaze").Get("checked").Truthy()
-	oppositeStart = document.Call("getElementById", "oppositeStart").Get("checked").Truthy()
+	args.height = integer("mazeHeight", 16)
+	args.width = integer("mazeWidth", 16)
+	args.seed = integer("randomSeed", 64)
+	args.algorithm = s.value("algorithm")
+	args.topology = s.value("topology")
+	args.levels = count("mazeLevels")
+	args.solution = s.checked("showSolution")
+	args.label = s.checked("labelMaze")
+	args.caption = s.value("labelCaption")
+	args.labelFormat = s.value("labelFormat")
+	args.labelRotation = count("labelRotation")
+	args.labelDirection = s.value("labelDirection")
+	args.oppositeStart = s.checked("oppositeStart")
+	args.ditherMethod = s.value("ditherMethod")
+	args.animateSolution = s.checked("animateSolution")
+	args.animateGeneration = s.checked("animateGeneration")
+	args.solutionDuration = s.number("solutionDuration")
+	args.routes = count("solutionRoutes")
+	if args.routes > maxRoutes {
+		args.routes = maxRoutes
+	}
+	args.targetSolutions = count("targetSolutions")
+	args.decoys = count("decoys")
+	args.braid = count("braid")
+	args.floodFill = s.checked("floodFill")
+	args.deadEndFill = s.checked("deadEndFill")
+	args.junctions = s.checked("showJunctions")
+	args.heatmap = s.checked("showHeatmap")
+	args.renderer = s.value("renderer")
+	args.play = s.checked("playMode")
+	args.shiftEvery = count("shiftEvery")
+	args.shiftWalls = count("shiftWalls")
+	args.enemies = count("enemies")
+	args.enemyKind = s.value("enemyKind")
+	args.checkpoints = count("checkpoints")
+	args.challenge = s.checked("challenge")
+	args.spareMoves = count("spareMoves")
+	args.rooms = count("worldRooms")
+	args.stages = count("chainStages")
+	args.pixelFormat = s.value("pixelFormat")
 
 	return
 }
 
 // Export the frame buffer. We invoke putMaze here, which actually
-// puts the pixel data into the canvas.
+// puts the pixel data into the canvas, and then draw the label on top.
 //
 // Note that image.RGBA.Pix just happens to be in the correct format
 // for Canvas ImageData. This means that we can simply pass a pointer
@@ -426,11 +638,22 @@ func getArguments() (height, width int64, solution, label, oppositeStart bool, s
 // frame buffer in linear memory.
 func export(label string) {
 	defer tr(ace("exporting frame buffer"))
-	putMaze.Invoke(
-		js.ValueOf(frameBuffer.Bounds().Dy()),
-		js.ValueOf(frameBuffer.Bounds().Dx()),
-		js.ValueOf(uintptr(unsafe.Pointer((*[1]uint8)(frameBuffer.Pix)))),
-		js.ValueOf(len(frameBuffer.Pix)),
-		js.ValueOf(label),
-	)
+	switch fb := frameBuffer.(type) {
+	case *image.RGBA:
+		putMaze.Invoke(
+			targetCanvas,
+			js.ValueOf(fb.Bounds().Dy()),
+			js.ValueOf(fb.Bounds().Dx()),
+			js.ValueOf(uintptr(unsafe.Pointer((*[1]uint8)(fb.Pix)))),
+			js.ValueOf(len(fb.Pix)),
+		)
+	case *image.Paletted:
+		exportPaletted(fb)
+	}
+
+	ctx := targetCanvas.Call("getContext", "2d")
+	drawLabel(ctx, label, frameBuffer.Bounds().Dx(), frameBuffer.Bounds().Dy(), currentLabelStyle)
+	if currentGame != nil {
+		currentGame.drawMovesLeft(ctx, frameBuffer.Bounds().Dx(), frameBuffer.Bounds().Dy())
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// A mask shapes a maze: only the cells it covers take part, so mazes
+// can be letters, logos, or holiday shapes. Masks are drawn as text,
+// one line a row and one character a cell, with spaces, dots, and
+// zeros outside the shape and anything else inside it, or as images,
+// with dark pixels inside. Either way a mask is stretched over the
+// maze, whatever its size.
+//
+// Cells outside the mask are treated as off the edge of the maze. The
+// start goes in the top row of the mask, and the finish in the bottom
+// row of the part of the mask joined to it; other parts get mazes of
+// their own, which can't be reached. Masked mazes are always carved
+// with the recursive backtracker, the only algorithm that can carve
+// several parts; asking for another is refused. Maze IDs don't
+// capture masks.
+
+var (
+	emptyMask       = errors.New("mask covers no cells")
+	maskedAlgorithm = errors.New("masked mazes are only carved with the recursive backtracker")
+)
+
+// Which cells of a grid a mask covers.
+type mask struct {
+	height, width int
+	in            []bool // Indexed like maze cells
+}
+
+// Read a mask drawn as text.
+func parseTextMask(text string) (*mask, error) {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r", ""), "\n"), "\n")
+	k := &mask{height: len(lines)}
+	for _, line := range lines {
+		if n := len([]rune(line)); n > k.width {
+			k.width = n
+		}
+	}
+
+	k.in = make([]bool, k.height*k.width)
+	covered := false
+	for y, line := range lines {
+		for x, r := range []rune(line) {
+			if r != ' ' && r != '.' && r != '0' {
+				k.in[y*k.width+x] = true
+				covered = true
+			}
+		}
+	}
+	if !covered {
+		return nil, emptyMask
+	}
+	return k, nil
+}
+
+// The mask stretched over a grid of the given size, indexed like maze
+// cells.
+func (k *mask) sample(height, width int) []bool {
+	in := make([]bool, height*width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			in[y*width+x] = k.in[(y*k.height/height)*k.width+x*k.width/width]
+		}
+	}
+	return in
+}
+
+// Whether a cell is part of the maze.
+func (m *maze) inMask(p position) bool {
+	return m.mask == nil || m.mask[p.y*m.width+p.x]
+}
+
+// Shape the maze, before it's generated, to the cells marked in the
+// given slice, indexed like its cells, and move the start and finish
+// into the shape. A mask covering no cells is ignored.
+func (m *maze) applyMask(in []bool) {
+	var top []position
+	for i, ok := range in {
+		p := position{x: i % m.width, y: i / m.width}
+		if ok && (len(top) == 0 || top[0].y == p.y) {
+			top = append(top, p)
+		}
+	}
+	if len(top) == 0 {
+		return
+	}
+	m.mask = in
+	m.start = top[m.rng.Intn(len(top))]
+
+	// The cells joined to the start, walls or no walls.
+	joined := make([]bool, len(m.cells))
+	joined[m.start.y*m.width+m.start.x] = true
+	queue := []position{m.start}
+	var bottom []position
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		switch {
+		case len(bottom) == 0 || p.y > bottom[0].y:
+			bottom = []position{p}
+		case p.y == bottom[0].y:
+			bottom = append(bottom, p)
+		}
+		for _, d := range []direction{north, south, east, west} {
+			if np, err := d.translate(p, m); err == nil && !joined[np.y*m.width+np.x] {
+				joined[np.y*m.width+np.x] = true
+				queue = append(queue, np)
+			}
+		}
+	}
+	m.finish = bottom[m.rng.Intn(len(bottom))]
+}
@@ -0,0 +1,61 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// The JS side of masks; see mask.go.
+
+var badMask = errors.New("mask must be text or ImageData")
+
+// The mask new mazes are shaped to, if any.
+var currentMask *mask = nil
+
+// Read a mask from ImageData: dark, opaque pixels are inside it.
+func imageMask(data js.Value) (*mask, error) {
+	k := &mask{height: data.Get("height").Int(), width: data.Get("width").Int()}
+	pixels := make([]byte, data.Get("data").Get("length").Int())
+	js.CopyBytesToGo(pixels, js.Global().Get("Uint8Array").New(data.Get("data").Get("buffer")))
+
+	k.in = make([]bool, k.height*k.width)
+	covered := false
+	for i := range k.in {
+		r, g, b, a := int(pixels[i*4]), int(pixels[i*4+1]), int(pixels[i*4+2]), pixels[i*4+3]
+		if a >= 128 && r*299+g*587+b*114 < 128*1000 {
+			k.in[i] = true
+			covered = true
+		}
+	}
+	if !covered {
+		return nil, emptyMask
+	}
+	return k, nil
+}
+
+// setMask(mask) shapes new mazes to the given mask, drawn as text or
+// given as ImageData; null goes back to rectangles. Returns null on
+// success, otherwise why not.
+func setMaskJS(this js.Value, args []js.Value) interface{} {
+	currentMask = nil
+	if len(args) == 0 || args[0].IsNull() || args[0].IsUndefined() {
+		return js.Null()
+	}
+
+	var err error
+	switch {
+	case args[0].Type() == js.TypeString:
+		currentMask, err = parseTextMask(args[0].String())
+	case args[0].InstanceOf(js.Global().Get("ImageData")):
+		currentMask, err = imageMask(args[0])
+	default:
+		err = badMask
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return js.Null()
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+)
+
+// The maze core: generating, solving, and drawing mazes. Like every
+// file without a js build tag it doesn't touch the browser, so it
+// builds and tests natively.
+
+// Mazes are simple structures.
+type maze struct {
+	start, finish position
+	oppositeStart bool // Whether the start and finish were put in opposite corners
+	height, width int
+	cells         []cell
+	rng           *rand.Rand
+	algorithm     string    // The name of the algorithm that generated it
+	carvings      []carving // The walls generation knocked down, in order, if it was recorded
+	recording     bool      // Whether carve records carvings
+	loops         bool      // Whether walls were knocked down after generation, making loops
+	decoys        bool      // Whether decoys were grown after generation; see decoy.go
+	mask          []bool    // The cells taking part, if not all of them; see mask.go
+}
+
+func (m *maze) at(p position) *cell {
+	return &m.cells[p.y*m.width+p.x]
+}
+
+// Maximum number of cells in height and/or width. How big cells are
+// drawn is up to the theme; see theme.go. Mazes bigger than
+// maxDrawnDimension, or too big for the theme's cells to fit in
+// maxDrawnSide pixels, are never drawn whole, only through a viewport
+// (see viewport.go), and what needs the whole image, like comparing,
+// explaining, batches, shaped mazes, animation, and play, keeps to it.
+const (
+	maxDimension      = 2000
+	maxDrawnDimension = 200
+)
+
+// Directions, and displacements to move in a given direction. Up and
+// down only lead between the floors of a maze with more than one (see
+// levels.go); square mazes are flat, and their cells only have the
+// first four.
+type direction int
+
+const (
+	north direction = iota
+	south
+	east
+	west
+	up
+	down
+)
+
+func (d direction) String() string {
+	return [...]string{"north", "south", "east", "west", "up", "down"}[d]
+}
+
+var outOfBounds = errors.New("out of bounds")
+
+// Cells outside the maze's mask, if it has one, are out of bounds.
+func (d direction) translate(p position, m *maze) (position, error) {
+	np := p
+	switch d {
+	case north:
+		np.y--
+	case south:
+		np.y++
+	case west:
+		np.x--
+	case east:
+		np.x++
+	case up, down:
+		return p, outOfBounds // Square mazes have just the one floor
+	}
+	if np.x < 0 || np.y < 0 || np.x >= m.width || np.y >= m.height || !m.inMask(np) {
+		return p, outOfBounds
+	}
+	return np, nil
+}
+
+func (d direction) opposite() direction {
+	return map[direction]direction{
+		north: south,
+		south: north,
+		east:  west,
+		west:  east,
+		up:    down,
+		down:  up,
+	}[d]
+}
+
+// A single cell.
+type cell struct {
+	openings [4]bool // Whether a given wall is open.
+}
+
+// Build a new maze with the given height and width.
+// Randomness is taken from the given RNG.
+// oppositeStart means to place start/end at opposing corners.
+func newMaze(height, width int, rng *rand.Rand, oppositeStart bool) *maze {
+	if width < 2 || height < 2 || rng == nil {
+		panic("invalid call to newMaze")
+	}
+
+	start := position{rng.Intn(width), 0}
+	end := position{rng.Intn(width), height - 1}
+	if oppositeStart {
+		start = position{0, 0}
+		end = position{width - 1, height - 1}
+	}
+	return &maze{
+		start:         start,
+		finish:        end,
+		oppositeStart: oppositeStart,
+		height:        height,
+		width:         width,
+		cells:         make([]cell, height*width),
+		rng:           rng,
+		algorithm:     generatorAlgorithm,
+	}
+}
+
+// Position is simply x/y coordinates.
+type position struct {
+	x, y int
+}
+
+// We use a stack of positions when generating and solving
+// the maze. This avoids using the call stack. Go has a very
+// deep call stack on most targets, but I'm not comfortable
+// asking WASM to give us a ~1500-level stack.
+type stack struct {
+	stack []position
+}
+
+func (s *stack) push(p position) {
+	s.stack = append(s.stack, p)
+}
+
+func (s stack) peek() position {
+	if len(s.stack) == 0 {
+		panic("stack underflow")
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *stack) pop() position {
+	p := s.peek()
+	s.stack = s.stack[:len(s.stack)-1]
+	return p
+}
+
+func (s stack) empty() bool {
+	return len(s.stack) == 0
+}
+
+func (s stack) len() int {
+	return len(s.stack)
+}
+
+// We precompute all possible permutations of orders to try digging.
+// This speeds up maze generation by ~25% from shuffling the directions
+// on each iteration through the maze generation loop.
+var permutations = [][]direction{
+	[]direction{north, south, east, west},
+	[]direction{north, south, west, east},
+	[]direction{north, east, south, west},
+	[]direction{north, east, west, south},
+	[]direction{north, west, south, east},
+	[]direction{north, west, east, south},
+	[]direction{south, north, east, west},
+	[]direction{south, north, west, east},
+	[]direction{south, east, north, west},
+	[]direction{south, east, west, north},
+	[]direction{south, west, north, east},
+	[]direction{south, west, east, north},
+	[]direction{east, north, south, west},
+	[]direction{east, north, west, south},
+	[]direction{east, south, north, west},
+	[]direction{east, south, west, north},
+	[]direction{east, west, north, south},
+	[]direction{east, west, south, north},
+	[]direction{west, north, south, east},
+	[]direction{west, north, east, south},
+	[]direction{west, south, north, east},
+	[]direction{west, south, east, north},
+	[]direction{west, east, north, south},
+	[]direction{west, east, south, north},
+}
+
+type visitedMap map[position]bool
+
+func (m visitedMap) contains(p position) (ok bool) {
+	_, ok = m[p]
+	return
+}
+
+func (m *maze) carve(p position, d direction) {
+	if m.recording {
+		m.carvings = append(m.carvings, carving{p, d})
+	}
+	m.at(p).openings[d] = true
+	if np, err := d.translate(p, m); err == nil {
+		m.at(np).openings[d.opposite()] = true
+	}
+}
+
+// The name of the algorithm generate uses, for labels.
+const generatorAlgorithm = "recursive backtracker"
+
+// The generation algorithms we know, by name. The key stands for the
+// algorithm in maze IDs; the default algorithm's is empty, so IDs from
+// before there was a choice still name the same mazes.
+var generators = []struct {
+	name     string
+	key      string
+	generate func(m *maze)
+}{
+	{generatorAlgorithm, "", (*maze).generate},
+	{"Prim's", "prim", (*maze).generatePrim},
+	{"Kruskal's", "kruskal", (*maze).generateKruskal},
+	{"Eller's", "eller", (*maze).generateEller},
+	{"Wilson's", "wilson", (*maze).generateWilson},
+}
+
+// Generate the maze with the named algorithm, or the default one if
+// there's no such algorithm or the maze is masked (see mask.go).
+func (m *maze) generateWith(name string) {
+	if m.mask != nil {
+		m.generate()
+		m.algorithm = generatorAlgorithm
+		return
+	}
+	for _, g := range generators {
+		if g.name == name {
+			g.generate(m)
+			m.algorithm = g.name
+			return
+		}
+	}
+	m.generate()
+}
+
+func (m *maze) generate() {
+	defer tr(ace("generating maze"))
+	m.backtrack(nil)
+}
+
+// The recursive backtracker generate uses, calling observe (if it's
+// given) with every step it takes; see explain.go.
+func (m *maze) backtrack(observe func(generationStep)) {
+	visited := make(visitedMap)
+	carveFrom := func(root position) {
+		stack := stack{[]position{root}}
+		for !stack.empty() {
+			found := false
+			p := stack.peek()
+			dirs := permutations[m.rng.Intn(len(permutations))]
+			for _, dir := range dirs {
+				np, err := dir.translate(p, m)
+				if err == nil && !visited.contains(np) {
+					m.carve(p, dir)
+					visited[np] = true
+					stack.push(np)
+					found = true
+					if observe != nil {
+						observe(generationStep{kind: carveStep, at: p, to: np, dir: dir, depth: stack.len()})
+					}
+					break
+				}
+			}
+
+			if !found {
+				stack.pop()
+				if observe != nil {
+					to := p
+					if !stack.empty() {
+						to = stack.peek()
+					}
+					observe(generationStep{kind: backtrackStep, at: p, to: to, depth: stack.len()})
+				}
+			}
+		}
+	}
+
+	carveFrom(m.start)
+
+	// The parts of a mask not joined to the start's get mazes of their
+	// own.
+	if m.mask != nil {
+		visited[m.start] = true
+		for i, in := range m.mask {
+			if p := (position{x: i % m.width, y: i / m.width}); in && !visited.contains(p) {
+				visited[p] = true
+				carveFrom(p)
+			}
+		}
+	}
+
+	m.openEnds()
+}
+
+// Solve via depth-first search.
+// Use the same stack mechanism as the maze generator.
+func (m maze) solve() []position {
+	defer tr(ace("solving maze"))
+	return m.search(nil)
+}
+
+// The depth-first search solve uses, calling observe (if it's given)
+// with every step it takes; see replay.go.
+func (m maze) search(observe func(searchStep)) []position {
+	stack := stack{[]position{m.start}}
+	visited := make(visitedMap)
+	visited[m.start] = true
+	if observe != nil {
+		observe(searchStep{p: m.start})
+	}
+
+SEARCH:
+	for !stack.empty() {
+		if visited.contains(m.finish) {
+			return stack.stack
+		}
+
+		pos := stack.peek()
+		for _, dir := range []direction{north, south, east, west} {
+			if np, err := dir.translate(pos, &m); err == nil && !visited.contains(np) && m.at(pos).openings[dir] {
+				visited[np] = true
+				stack.push(np)
+				if observe != nil {
+					observe(searchStep{p: np})
+				}
+				continue SEARCH
+			}
+		}
+		stack.pop()
+		if observe != nil {
+			observe(searchStep{p: pos, popped: true})
+		}
+	}
+
+	panic("maze has no solution")
+}
+
+// The size (in pixels) of the image of the maze.
+func (m *maze) imageSize() (width, height int) {
+	width = m.width*cellWidth + border*2
+	if width < minImageWidth {
+		width = minImageWidth
+	}
+	return width, m.height*cellWidth + border*2
+}
+
+// The cell under the given point (in pixels) of the maze's image, if
+// there is one.
+func (m *maze) cellAt(x, y float64) (position, bool) {
+	cx, cy := math.Floor((x-float64(border))/float64(cellWidth)), math.Floor((y-float64(border))/float64(cellWidth))
+	if cx < 0 || cy < 0 || cx >= float64(m.width) || cy >= float64(m.height) {
+		return position{}, false
+	}
+	return position{x: int(cx), y: int(cy)}, true
+}
+
+// Find the number of steps from the given cell to every other cell,
+// via breadth-first search. The result is indexed like m.cells;
+// unreachable cells are -1.
+func (m *maze) distances(from position) []int {
+	return m.distancesFrom([]position{from})
+}
+
+// Find the number of steps to every cell from the nearest of the given
+// ones, likewise.
+func (m *maze) distancesFrom(sources []position) []int {
+	distances := make([]int, len(m.cells))
+	for i := range distances {
+		distances[i] = -1
+	}
+	for _, p := range sources {
+		distances[p.y*m.width+p.x] = 0
+	}
+
+	queue := append([]position(nil), sources...)
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		for _, dir := range []direction{north, south, east, west} {
+			np, err := dir.translate(pos, m)
+			if err != nil || !m.at(pos).openings[dir] || distances[np.y*m.width+np.x] >= 0 {
+				continue
+			}
+			distances[np.y*m.width+np.x] = distances[pos.y*m.width+pos.x] + 1
+			queue = append(queue, np)
+		}
+	}
+
+	return distances
+}
+
+// Find a shortest path between any two cells, by walking downhill
+// through the distances to the destination. Returns nil if there's no
+// way through.
+func (m *maze) findPath(from, to position) []position {
+	distances := m.distances(to)
+	if distances[from.y*m.width+from.x] < 0 {
+		return nil
+	}
+
+	path := []position{from}
+	for pos := from; pos != to; {
+		for _, dir := range []direction{north, south, east, west} {
+			np, err := dir.translate(pos, m)
+			if err == nil && m.at(pos).openings[dir] && distances[np.y*m.width+np.x] == distances[pos.y*m.width+pos.x]-1 {
+				pos = np
+				break
+			}
+		}
+		path = append(path, pos)
+	}
+
+	return path
+}
+
+// Draw the maze onto the given image, which must be the maze's
+// image size.
+func (m *maze) drawOnto(img draw.Image) {
+	width, height := m.imageSize()
+	fill(img, 0, height, 0, width, background)
+
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if p := (position{x: x, y: y}); m.inMask(p) {
+				m.drawCell(img, x, y, m.at(p))
+			}
+		}
+	}
+}
+
+// Draw the solution path.
+func (m *maze) drawPath(img draw.Image, path []position) {
+	defer tr(ace("drawing solution"))
+
+	for i := 1; i < len(path); i++ {
+		m.drawSegment(img, path[i-1], path[i])
+	}
+}
+
+// Draw one step of the solution path, between adjacent cells.
+// Note that we sort our origin and destination points
+// so that we don't have any gaps and it's a nice smooth
+// connected path.
+func (m *maze) drawSegment(img draw.Image, prev, pos position) {
+	m.drawLine(img, prev, pos, solutionColor)
+}
+
+// Draw a line in the given color between the centers of two
+// adjacent cells.
+func (m *maze) drawLine(img draw.Image, prev, pos position, col image.Image) {
+	if pos.x == prev.x {
+		first, last := prev, pos
+		if first.y > last.y {
+			first, last = last, first
+		}
+		vLine(img, first.x*cellWidth+border+halfCellWidth, first.y*cellWidth+border+halfCellWidth, last.y*cellWidth+border+halfCellWidth, col)
+	}
+	if pos.y == prev.y {
+		first, last := prev, pos
+		if first.x > last.x {
+			first, last = last, first
+		}
+		hLine(img, first.x*cellWidth+border+halfCellWidth, first.y*cellWidth+border+halfCellWidth, last.x*cellWidth+border+halfCellWidth, col)
+	}
+}
+
+// Fill the image with a given color.
+func fill(img draw.Image, y0, y1, x0, x1 int, color color.Color) {
+	defer tr(ace("clearing image"))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color}, image.Point{0, 0}, draw.Src)
+}
+
+// Draw a horizontal line from p1 -> p2, as thick as the theme says.
+// Thick lines have square ends, reaching as far past their end points
+// as they do to either side, so that lines meeting at a corner join.
+func hLine(img draw.Image, x1, y, x2 int, col image.Image) {
+	before, after := lineReach()
+	draw.Draw(img, image.Rect(x1-before, y-before, x2+after+1, y+after+1), col, image.Point{0, 0}, draw.Over)
+}
+
+// Draw a vertical line from p1 -> p2, likewise.
+func vLine(img draw.Image, x, y1, y2 int, col image.Image) {
+	before, after := lineReach()
+	draw.Draw(img, image.Rect(x-before, y1-before, x+after+1, y2+after+1), col, image.Point{0, 0}, draw.Over)
+}
+
+// Draw an individual cell.
+func (m *maze) drawCell(img draw.Image, x, y int, c *cell) {
+	if !c.openings[north] {
+		hLine(img, x*cellWidth+border, y*cellWidth+border, x*cellWidth+border+cellWidth, wallColor)
+	}
+
+	if !c.openings[south] {
+		hLine(img, x*cellWidth+border, y*cellWidth+border+cellWidth, x*cellWidth+border+cellWidth, wallColor)
+	}
+
+	if !c.openings[west] {
+		vLine(img, x*cellWidth+border, y*cellWidth+border, y*cellWidth+border+cellWidth, wallColor)
+	}
+
+	if !c.openings[east] {
+		vLine(img, x*cellWidth+border+cellWidth, y*cellWidth+border, y*cellWidth+border+cellWidth, wallColor)
+	}
+}
@@ -0,0 +1,158 @@
+// Code generated by mazetypes from api.go. DO NOT EDIT.
+
+/** Options for init; reserveMemory sets aside the largest frame buffer up front, so memory never grows while drawing. */
+type MazeInitOptions = { reserveMemory?: boolean };
+
+/** A cell of the maze, counting from zero at the top left. */
+type MazeCell = { x: number; y: number };
+
+/** The shape of a maze, and how hard it is: the solution's length in cells, its decision points, the maze's dead ends and junctions, and how far its longest false branch runs from the solution. Distances are in steps between cells; distances holds each cell's from the start, indexed like MazeData's cells, with -1 for cells that can't be reached. */
+type MazeAnalysis = { diameter: number; farthest: [MazeCell, MazeCell]; averagePath: number; solution: number; difficulty: number; deadEnds: number; junctions: number; longestBranch: number; distances: number[] };
+
+/** The formats the current maze can be exported in. */
+type MazeExportFormat = "png" | "svg" | "pdf" | "hpgl" | "brf" | "tactile" | "thermal" | "pbm" | "apng" | "layer" | "stitch" | "bricks" | "cutting" | "openscad" | "poster" | "html" | "xlsx";
+
+/** A maze as handed to custom renderers. Cells run left to right, top to bottom; each is a bit mask of its openings: 1 north, 2 south, 4 east, 8 west. */
+type MazeData = { width: number; height: number; imageWidth: number; imageHeight: number; cells: Uint8Array; start: MazeCell; finish: MazeCell; solution: MazeCell[] | null; label: string };
+
+/** Settings for generate, overriding the page's; any setting can also be named by its input's ID. Settings neither gives take the settings form's defaults. */
+type MazeOptions = { height?: number; width?: number; levels?: number; seed?: number; algorithm?: string; solution?: boolean; label?: boolean; solutions?: number; routes?: number; animate?: boolean; duration?: number; [setting: string]: string | number | boolean | undefined };
+
+/** A newly generated maze; id is null if it was changed after generation, as for MazeGen.id. If it was drawn into the RGBA frame buffer, pixels is the buffer's offset in the module's memory and length its size in bytes, good until the next maze is drawn. */
+type MazeResult = { id: string | null; seed: number; algorithm: string; width: number; height: number; imageWidth: number; imageHeight: number; solution: MazeCell[] | null; pixels?: number; length?: number };
+
+/** A custom renderer: draw on the canvas and return nothing, or return RGBA pixels of the image size to be put on the canvas. */
+type MazeRenderer = (maze: MazeData, canvas: HTMLCanvasElement) => ImageData | Uint8ClampedArray | void;
+
+/** The maze parameters carried in an exported PNG. */
+type MazePNGInfo = { id: string; algorithm: string; difficulty: number; decoys: number; solutions: number; braid: number };
+
+/** Options for batchZip; unique skips near-duplicate mazes, and formats defaults to all three. */
+type MazeBatchOptions = { unique?: boolean; formats?: ("png" | "svg" | "pdf")[] };
+
+/** Options for print, overriding the page's; paper lays the maze out on a sheet of that size, lineWidth is the width of walls in millimetres, and marks and bleed (in millimetres) add print marks to PDFs. */
+type MazePrintOptions = { paper?: "letter" | "a4" | ""; lineWidth?: number; marks?: boolean; bleed?: number };
+
+/** A recently generated maze, with its maze code, and a thumbnail at most 128 pixels on a side; id is null if it was changed after generation, as for MazeGen.id. */
+type MazeHistoryEntry = { id: string | null; code: string; decoys: number; solutions: number; braid: number; thumbnail: ImageData };
+
+/** The pointer moving onto, clicking, or leaving the maze's cells; walls are true where the cell has one. Leave events have only a type. */
+type MazeCellEvent = { type: "hover" | "click" | "leave"; cell?: MazeCell; walls?: { north: boolean; south: boolean; east: boolean; west: boolean }; start?: boolean; finish?: boolean };
+
+/** A step of generation replayed in explain mode, described in text; depth is the stack's depth after it. A step seen again after seeking backwards has the same index. */
+type MazeExplainStep = { index: number; steps: number; type: "carve" | "backtrack" | "done"; text: string; at: MazeCell; to: MazeCell; depth: number; direction?: "north" | "south" | "east" | "west" };
+
+/** How a game is going: where the player is, how many moves they've made, and for how long they've played (or did), in milliseconds; failed is true if they ran out of moves. */
+type MazeGameStatus = { at: MazeCell; moves: number; time: number; finished: boolean; failed: boolean };
+
+/** The part of a maze too big to draw whole that the canvas shows: the point of the maze's image (in image pixels) at the canvas's top left, how many canvas pixels there are to an image pixel, and the sizes of the canvas and of the whole image. */
+type MazeViewport = { x: number; y: number; zoom: number; width: number; height: number; imageWidth: number; imageHeight: number };
+
+/** Go runtime memory statistics, in bytes; lastPause is in milliseconds. */
+type MazeMemStats = { heapInUse: number; heapAlloc: number; sys: number; gcCycles: number; lastPause: number };
+
+interface MazeGenAPI {
+    /** Attach to the page, drawing on the given canvas (or the first matching the selector); null on success, otherwise why not. */
+    init(canvas: HTMLCanvasElement | string, options?: MazeInitOptions): string | null;
+
+    /** Detach from the page, stopping anything in progress and freeing the current maze. */
+    dispose(): void;
+
+    /** Dispose, then remove MazeGen and the other global objects and stop the Go program, so the module can be replaced. */
+    shutdown(): void;
+
+    /** Generate and draw a new maze with the page's current settings, overridden by any options given; null if the settings won't do, or the maze isn't a single floor of squares. */
+    generate(options?: MazeOptions): MazeResult | null;
+
+    /** A code carrying the current maze, walls and all, short enough for a URL; null if there's no maze or it isn't made of squares. */
+    encode(): string | null;
+
+    /** Draw the maze a code carries and make it the current maze, with the page's settings overridden by any options given (those saying how to draw it, and whether to show the solution, matter; those saying how to generate it don't); null if the code is malformed. */
+    decode(code: string, options?: MazeOptions): MazeResult | null;
+
+    /** The solution of the current maze, from start to finish, or null if there's no maze. */
+    solve(): MazeCell[] | null;
+
+    /** SVG path data for the solution of the current maze, through the centers of its cells in image pixels, or null if there's no maze. */
+    solutionPath(): string | null;
+
+    /** The current maze as a print-quality vector file, with its solution if it's showing, or in PDFs on a layer that starts hidden; null if there's no maze, it's too big to draw whole, or the format is unknown. */
+    print(format: "svg" | "pdf", options?: MazePrintOptions): Blob | null;
+
+    /** Offer the current maze as a download in the given format, unless it's too big to draw whole; false if the format is unknown. */
+    export(format: MazeExportFormat): boolean;
+
+    /** Analyze the current maze. */
+    analyze(): MazeAnalysis | null;
+
+    /** A shortest path between two cells of the current maze, or null if there's none. */
+    findPath(from: MazeCell, to: MazeCell): MazeCell[] | null;
+
+    /** The ID of the current maze, for verify; null if it was changed after generation (by decoys, braiding, extra solutions, or a mask), when verify takes its code (see encode) instead. */
+    id(): string | null;
+
+    /** Check that moves (N, S, E, and W) solve the maze with the given ID or code; null if they do, otherwise why not. */
+    verify(id: string, moves: string): string | null;
+
+    /** Estimate how long generating and drawing a maze will take, in milliseconds. */
+    predict(params: { height: number; width: number; algorithm?: string }): number | null;
+
+    /** Seeds for a batch of mazes with the current settings, optionally skipping near-duplicates. */
+    seeds(count: number, unique?: boolean): number[] | null;
+
+    /** A ZIP archive of a batch of mazes with the current settings, each as a puzzle and a key. */
+    batchZip(count: number, options?: MazeBatchOptions): Blob | null;
+
+    /** The mazes generated most recently, newest first. */
+    history(): MazeHistoryEntry[];
+
+    /** Call back with the history whenever a maze is generated; null stops. */
+    onHistory(callback: ((entries: MazeHistoryEntry[]) => void) | null): void;
+
+    /** Draw the maze at the given place in the history again, just as it was, and put back its settings; false if there's none there. */
+    restore(index: number): boolean;
+
+    /** Call back as the pointer moves onto, clicks, or leaves the maze's cells; null stops. */
+    onCell(callback: ((event: MazeCellEvent) => void) | null): void;
+
+    /** The viewport the current maze is drawn through, moved first to whatever part of it is given; null if the maze is small enough to be drawn whole. The viewport is kept on the maze's image. */
+    view(viewport?: { x?: number; y?: number; zoom?: number }): MazeViewport | null;
+
+    /** Move the viewport by the given number of canvas pixels, and return it; null if there's none, and false if dx or dy isn't a finite number. */
+    pan(dx: number, dy: number): MazeViewport | null | false;
+
+    /** Zoom the viewport by the given factor, keeping the point under the given canvas pixel (by default, the middle) where it is, and return it; null if there's none, and false if factor isn't a positive finite number or x and y aren't finite numbers. */
+    zoom(factor: number, x?: number, y?: number): MazeViewport | null | false;
+
+    /** How the game in play mode is going, or null if there's none. */
+    gameStatus(): MazeGameStatus | null;
+
+    /** Call back with the game's status every time the player moves; null stops. */
+    onMove(callback: ((status: MazeGameStatus) => void) | null): void;
+
+    /** Call back with every step explain mode replays; null stops. */
+    onExplain(callback: ((step: MazeExplainStep) => void) | null): void;
+
+    /** Shape new mazes to a mask, drawn as text (spaces, dots, and zeros outside the shape) or given as an image (dark pixels inside), stretched over the maze; null goes back to rectangles. Masked mazes are only carved with the recursive backtracker, and generating one with another algorithm fails. Returns null on success, otherwise why not. */
+    setMask(mask: string | ImageData | null): string | null;
+
+    /** Register a custom renderer, chosen by setting the renderer to its name; null removes it. */
+    registerRenderer(name: string, render: MazeRenderer | null): void;
+
+    /** The maze parameters in an exported PNG, or null if it has none. */
+    readPNG(data: Uint8Array | ArrayBuffer): MazePNGInfo | null;
+
+    /** Set the page's settings from an exported PNG and generate its maze again; false if it has no parameters. */
+    loadPNG(data: Uint8Array | ArrayBuffer): boolean;
+
+    /** The page's settings, all but the seed, as a JSON profile; null if the page has no settings form. */
+    exportSettings(): string | null;
+
+    /** Set the page's settings from a JSON profile and, if there's a maze, generate a new one; null on success, otherwise why not. */
+    importSettings(json: string): string | null;
+
+    /** Go runtime memory statistics. */
+    memStats(): MazeMemStats;
+}
+
+declare const MazeGen: MazeGenAPI;
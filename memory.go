@@ -0,0 +1,54 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"runtime"
+	"syscall/js"
+)
+
+// WASM memory only ever grows, and every time it does JS has to find
+// the frame buffer again in the new memory (see putMaze). Pages that
+// would rather pay for the largest frame buffer up front can ask us to
+// set it aside when we start, and then every frame buffer is carved out
+// of it and memory never has to grow for drawing. See attach.
+
+// Memory set aside for frame buffers, if we were asked to.
+var reservedPixels []uint8 = nil
+
+// Set aside enough memory for the largest frame buffer.
+func reserveMemory() {
+	defer tr(ace("reserving frame buffer memory"))
+	reservedPixels = make([]uint8, maxDrawnSide*maxDrawnSide*4)
+}
+
+func memStatsJS(this js.Value, args []js.Value) interface{} {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	lastPause := 0.0
+	if stats.NumGC > 0 {
+		lastPause = float64(stats.PauseNs[(stats.NumGC+255)%256]) / 1e6
+	}
+
+	result := js.Global().Get("Object").New()
+	result.Set("heapInUse", stats.HeapInuse)
+	result.Set("heapAlloc", stats.HeapAlloc)
+	result.Set("sys", stats.Sys)
+	result.Set("gcCycles", stats.NumGC)
+	result.Set("lastPause", lastPause)
+	return result
+}
+
+// Build the JS-facing debugging API. memStats() returns highlights of
+// the Go runtime's memory statistics: bytes of heap in use, bytes got
+// from the system (which is roughly the size of WASM memory), the
+// number of garbage collections, and how long the last one paused us,
+// in milliseconds.
+func debugControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	memStats := js.FuncOf(memStatsJS)
+	controls.Set("memStats", memStats)
+	return controls, []js.Func{memStats}
+}
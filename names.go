@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// Every maze gets a name, made up from its seed, so that the same maze
+// always has the same one: "The Twisting Halls of Borin". Puzzle books
+// can title their mazes with it, and children can have a maze of
+// their own.
+
+var (
+	nameAdjectives = []string{
+		"Twisting", "Winding", "Forgotten", "Whispering", "Crooked", "Endless",
+		"Tangled", "Shadowed", "Hidden", "Echoing", "Mossy", "Crumbling",
+		"Silver", "Wandering", "Sleepy", "Glittering", "Dizzy", "Ancient",
+	}
+	namePlaces = []string{
+		"Halls", "Passages", "Tunnels", "Corridors", "Catacombs", "Hedges",
+		"Burrows", "Vaults", "Cellars", "Paths", "Warrens", "Cloisters",
+		"Caverns", "Galleries", "Gardens", "Stairs",
+	}
+	nameOnsets   = []string{"B", "D", "F", "G", "K", "L", "M", "N", "P", "R", "S", "T", "V", "Z", "Br", "Gr", "Th", "Wr"}
+	nameVowels   = []string{"a", "e", "i", "o", "u", "au", "ei", "y"}
+	nameCodas    = []string{"n", "r", "l", "th", "d", "m", "x", "g", "ck", "s"}
+	nameFormats  = []string{"The {adjective} {place} of {owner}", "{owner}'s {adjective} {place}", "The {place} of {owner} the {epithet}"}
+	nameEpithets = []string{"Lost", "Bold", "Clever", "Lonely", "Wise", "Lucky", "Brave", "Sly"}
+)
+
+// Make up a name for whoever the maze belongs to: two or three
+// syllables, capitalized.
+func ownerName(rng *rand.Rand) string {
+	var name strings.Builder
+	syllables := 2 + rng.Intn(2)
+	for i := 0; i < syllables; i++ {
+		onset := nameOnsets[rng.Intn(len(nameOnsets))]
+		if i > 0 {
+			onset = strings.ToLower(onset)
+		}
+		name.WriteString(onset)
+		name.WriteString(nameVowels[rng.Intn(len(nameVowels))])
+	}
+	name.WriteString(nameCodas[rng.Intn(len(nameCodas))])
+	return name.String()
+}
+
+// The name of the maze with the given seed.
+func mazeName(seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	pick := func(words []string) string { return words[rng.Intn(len(words))] }
+	format := pick(nameFormats)
+	return strings.NewReplacer(
+		"{adjective}", pick(nameAdjectives),
+		"{place}", pick(namePlaces),
+		"{epithet}", pick(nameEpithets),
+		"{owner}", ownerName(rng),
+	).Replace(format)
+}
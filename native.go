@@ -0,0 +1,18 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// The generator itself only runs in the browser. Built for anything
+// else, this package is just the maze core (everything without a js
+// build tag), which is enough for go test and for working on the
+// algorithms and renderers without one.
+func main() {
+	fmt.Fprintln(os.Stderr, "twistylittlepassages runs in the browser; build it with GOOS=js GOARCH=wasm")
+	os.Exit(1)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Render the maze as an OpenSCAD script for 3D printing. Rather than a
+// fixed mesh, the script lists the walls (see walls) in cell units and
+// builds them from variables at the top, so that the cell size, wall
+// thickness and height, and base can be changed in OpenSCAD's
+// customizer without generating the maze again.
+func (m *maze) openSCAD() string {
+	defer tr(ace("rendering openscad"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %d x %d maze.\n\n", m.height, m.width)
+	b.WriteString("cell = 10;           // Width of a cell (mm)\n")
+	b.WriteString("wall_thickness = 1.6; // (mm)\n")
+	b.WriteString("wall_height = 8;     // Above the base (mm)\n")
+	b.WriteString("base_thickness = 2;  // 0 for no base (mm)\n\n")
+
+	fmt.Fprintf(&b, "rows = %d;\ncolumns = %d;\n\n", m.height, m.width)
+
+	// Rows are flipped so the maze reads the same way up as on screen,
+	// with OpenSCAD's y axis pointing away from the viewer.
+	b.WriteString("// Walls, as [x0, y0, x1, y1] in cells, from the top left.\nwalls = [\n")
+	for _, s := range m.walls() {
+		fmt.Fprintf(&b, "    [%d, %d, %d, %d],\n", s.x0, s.y0, s.x1, s.y1)
+	}
+	b.WriteString("];\n\n")
+
+	b.WriteString(`module wall(w) {
+    x = min(w[0], w[2]) * cell - wall_thickness / 2;
+    y = (rows - max(w[1], w[3])) * cell - wall_thickness / 2;
+    translate([x, y, base_thickness])
+        cube([abs(w[2] - w[0]) * cell + wall_thickness,
+              abs(w[3] - w[1]) * cell + wall_thickness,
+              wall_height]);
+}
+
+union() {
+    if (base_thickness > 0)
+        translate([-wall_thickness / 2, -wall_thickness / 2, 0])
+            cube([columns * cell + wall_thickness, rows * cell + wall_thickness, base_thickness]);
+    for (w = walls)
+        wall(w);
+}
+`)
+	return b.String()
+}
@@ -0,0 +1,155 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"syscall/js"
+)
+
+// Settings come from the settings form, named by their input's ID, but
+// the form is only one place to get them. Pages without it, or that
+// want different settings for one maze, pass MazeGen.generate an
+// options object, whose properties take the place of the form's
+// inputs; a setting neither gives is the form's default. Options can
+// be named by their input's ID or, for the common ones, more briefly.
+
+var badNumber = errors.New("not a whole number")
+
+// Short names for options, and the inputs they stand for.
+var optionNames = map[string]string{
+	"height":    "mazeHeight",
+	"width":     "mazeWidth",
+	"levels":    "mazeLevels",
+	"seed":      "randomSeed",
+	"solution":  "showSolution",
+	"label":     "labelMaze",
+	"solutions": "targetSolutions",
+	"routes":    "solutionRoutes",
+	"animate":   "animateSolution",
+	"duration":  "solutionDuration",
+	"paper":     "printPaper",
+	"marks":     "printMarks",
+}
+
+// The settings form's defaults, for settings that aren't on the page.
+// Checkboxes default to unchecked and anything not here to empty.
+var settingDefaults = map[string]string{
+	"mazeHeight":       "15",
+	"mazeWidth":        "15",
+	"randomSeed":       "0",
+	"algorithm":        generatorAlgorithm,
+	"topology":         squareTopology,
+	"mazeLevels":       "2",
+	"solutionRoutes":   "1",
+	"solutionDuration": "2000",
+	"targetSolutions":  "1",
+	"decoys":           "0",
+	"braid":            "0",
+	"labelRotation":    "0",
+	"labelDirection":   "auto",
+	"ditherMethod":     "none",
+	"renderer":         "raster",
+	"fitMode":          "none",
+	"pixelFormat":      "rgba",
+	"shiftEvery":       "0",
+	"shiftWalls":       "3",
+	"enemies":          "0",
+	"enemyKind":        "patrol",
+	"checkpoints":      "0",
+	"spareMoves":       "10",
+	"worldRooms":       "1",
+	"chainStages":      "3",
+	"cellSize":         "12",
+	"borderSize":       "40",
+	"wallThickness":    "1",
+	"wallColor":        "#000000",
+	"backgroundColor":  "#ffffff",
+	"solutionColor":    "#ff0000",
+}
+
+// Where settings are read from: the options object, if there is one,
+// then the page.
+type settingSource struct {
+	options js.Value
+}
+
+// Settings from the page alone.
+var pageSettings = settingSource{js.Undefined()}
+
+// The number a JS argument holds, and whether it's a finite one.
+func finiteArg(v js.Value) (float64, bool) {
+	if v.Type() != js.TypeNumber {
+		return 0, false
+	}
+	n := v.Float()
+	return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+}
+
+// The element with the given ID, which is null if the page hasn't got
+// one.
+func element(id string) js.Value {
+	return js.Global().Get("document").Call("getElementById", id)
+}
+
+// The option standing for the input with the given ID, undefined if
+// there's none.
+func (s settingSource) option(id string) js.Value {
+	if s.options.Type() != js.TypeObject {
+		return js.Undefined()
+	}
+	if v := s.options.Get(id); !v.IsUndefined() {
+		return v
+	}
+	for name, input := range optionNames {
+		if input == id {
+			return s.options.Get(name)
+		}
+	}
+	return js.Undefined()
+}
+
+// The value of the setting with the given ID.
+func (s settingSource) value(id string) string {
+	switch v := s.option(id); v.Type() {
+	case js.TypeString:
+		return v.String()
+	case js.TypeNumber:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case js.TypeBoolean:
+		return strconv.FormatBool(v.Bool())
+	}
+	if e := element(id); !e.IsNull() {
+		return e.Get("value").String()
+	}
+	return settingDefaults[id]
+}
+
+// Whether the checkbox setting with the given ID is checked.
+func (s settingSource) checked(id string) bool {
+	if v := s.option(id); !v.IsUndefined() && !v.IsNull() {
+		return v.Truthy()
+	}
+	if e := element(id); !e.IsNull() {
+		return e.Get("checked").Truthy()
+	}
+	return false
+}
+
+// The numeric setting with the given ID, or NaN if it isn't a number.
+func (s settingSource) number(id string) float64 {
+	if v := s.option(id); v.Type() == js.TypeNumber {
+		return v.Float()
+	}
+	if e := element(id); !e.IsNull() {
+		return e.Get("valueAsNumber").Float()
+	}
+	n, err := strconv.ParseFloat(settingDefaults[id], 64)
+	if err != nil {
+		return js.Global().Get("NaN").Float()
+	}
+	return n
+}
@@ -0,0 +1,42 @@
+package main
+
+import "math"
+
+// Vector output is normally sized to the maze, a pixel of the raster
+// image to a unit of the SVG or a point of the PDF, which suits
+// putting it in another document. For printing it can instead be laid
+// out on a sheet of paper (one of paperSizes), scaled to fit inside a
+// margin and centred, with walls drawn at a chosen width however big
+// or small that makes the cells.
+
+const paperMargin = 12.7 // Margin around a maze laid out on paper (in mm)
+
+// How to lay vector output out on the page.
+type pageSetup struct {
+	paper     string  // Name of the paper size; anything else fits the page to the maze
+	lineWidth float64 // Width of walls (in mm), or 0 for a pixel's width
+}
+
+// Where the maze goes on the page, in the output's units.
+type pageLayout struct {
+	width, height float64 // Of the page
+	x, y          float64 // Of the maze's top left corner
+	scale         float64 // Output units per pixel of the maze
+	stroke        float64 // Width of walls, in pixels of the maze
+}
+
+// Lay out a maze image of the given size on the page, in output units
+// of which there are the given number to the millimetre.
+func (p pageSetup) layout(width, height int, unitsPerMM float64) pageLayout {
+	l := pageLayout{width: float64(width), height: float64(height), scale: 1, stroke: 1}
+	if size, ok := paperSizes[p.paper]; ok {
+		l.width, l.height = size[0]*unitsPerMM, size[1]*unitsPerMM
+		margin := paperMargin * unitsPerMM
+		l.scale = math.Min((l.width-margin*2)/float64(width), (l.height-margin*2)/float64(height))
+		l.x, l.y = (l.width-float64(width)*l.scale)/2, (l.height-float64(height)*l.scale)/2
+	}
+	if p.lineWidth > 0 {
+		l.stroke = p.lineWidth * unitsPerMM / l.scale
+	}
+	return l
+}
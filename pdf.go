@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Print shops want a page trimmed to size from a bigger sheet: an
+// area past the trim that's printed anyway (the bleed), so a slightly
+// misaligned cut doesn't leave a paper-colored edge, with crop marks
+// showing where to cut and registration marks for lining up the inks
+// out beyond that (the slug). Sizes are in points.
+type printMarks struct {
+	enabled bool
+	bleed   float64
+}
+
+const (
+	slugSize         = 36 // Margin outside the trim for marks (in points)
+	cropMarkLength   = 18 // (in points)
+	registrationSize = 8  // Radius of registration marks (in points)
+	pointsPerMM      = 72 / 25.4
+)
+
+// Draw marks around a trim box of the given size, a slug in from the
+// page's bottom left. Marks are in registration color, all four inks
+// at full strength, so they print on every plate.
+func (p printMarks) draw(content *strings.Builder, width, height float64) {
+	left, bottom := float64(slugSize), float64(slugSize)
+	right, top := left+width, bottom+height
+	gap := p.bleed + 3
+
+	content.WriteString("1 1 1 1 K 0.25 w 0 J\n")
+	for _, x := range []float64{left, right} {
+		dx := -1.0
+		if x == right {
+			dx = 1
+		}
+		for _, y := range []float64{bottom, top} {
+			dy := -1.0
+			if y == top {
+				dy = 1
+			}
+			fmt.Fprintf(content, "%.2f %.2f m %.2f %.2f l\n", x+dx*gap, y, x+dx*(gap+cropMarkLength), y)
+			fmt.Fprintf(content, "%.2f %.2f m %.2f %.2f l\n", x, y+dy*gap, x, y+dy*(gap+cropMarkLength))
+		}
+	}
+
+	// A circle with a cross through it, centred in the slug on each side.
+	r := float64(registrationSize)
+	k := r * 0.5523 // For approximating a quarter circle with a Bézier curve
+	for _, c := range [][2]float64{
+		{(left + right) / 2, top + slugSize/2}, {(left + right) / 2, bottom - slugSize/2},
+		{left - slugSize/2, (bottom + top) / 2}, {right + slugSize/2, (bottom + top) / 2},
+	} {
+		x, y := c[0], c[1]
+		fmt.Fprintf(content, "%.2f %.2f m ", x+r, y)
+		fmt.Fprintf(content, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+r, y+k, x+k, y+r, x, y+r)
+		fmt.Fprintf(content, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-k, y+r, x-r, y+k, x-r, y)
+		fmt.Fprintf(content, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-r, y-k, x-k, y-r, x, y-r)
+		fmt.Fprintf(content, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+k, y-r, x+r, y-k, x+r, y)
+		fmt.Fprintf(content, "%.2f %.2f m %.2f %.2f l %.2f %.2f m %.2f %.2f l\n", x-r*1.5, y, x+r*1.5, y, x, y-r*1.5, x, y+r*1.5)
+	}
+	content.WriteString("S\n")
+}
+
+// A color as PDF operands: its red, green, and blue, from 0 to 1.
+func pdfColor(c color.RGBA) string {
+	return fmt.Sprintf("%.3g %.3g %.3g", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// Render the maze as a one-page PDF, in the same geometry as the SVG
+// (one pixel to a point) or laid out on paper. The solution is always
+// included, on an optional content group (a layer) that starts hidden,
+// so one file is both the puzzle and, with the layer shown, its answer
+// key. With print marks, the page is the trim size plus a slug all
+// round.
+func (m *maze) pdf(path []position, label string, marks printMarks, page pageSetup) []byte {
+	defer tr(ace("rendering pdf"))
+
+	width, height := m.imageSize()
+	l := page.layout(width, height, pointsPerMM)
+	offset, pageWidth, pageHeight := 0.0, l.width, l.height
+	if marks.enabled {
+		offset, pageWidth, pageHeight = slugSize, l.width+slugSize*2, l.height+slugSize*2
+	}
+
+	// Flip the page so y runs down, like the raster renderer's.
+	var content strings.Builder
+	content.WriteString("q\n")
+	if marks.enabled {
+		// The maze's own border is the background, so the bleed can be
+		// too.
+		fmt.Fprintf(&content, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(currentTheme.background),
+			offset-marks.bleed, offset-marks.bleed, l.width+marks.bleed*2, l.height+marks.bleed*2)
+	}
+	fmt.Fprintf(&content, "%.5f 0 0 %.5f %.2f %.2f cm\n", l.scale, -l.scale, offset+l.x, offset+l.height-l.y)
+	fmt.Fprintf(&content, "%s rg 0 0 %d %d re f\n", pdfColor(currentTheme.background), width, height)
+	fmt.Fprintf(&content, "%s RG %.3f w 2 J\n", pdfColor(currentTheme.wall), l.stroke)
+	for _, s := range m.walls() {
+		fmt.Fprintf(&content, "%d %d m %d %d l\n", s.x0*cellWidth+border, s.y0*cellWidth+border, s.x1*cellWidth+border, s.y1*cellWidth+border)
+	}
+	content.WriteString("S\n")
+
+	if len(path) > 1 {
+		fmt.Fprintf(&content, "/OC /solution BDC\n%s RG\n", pdfColor(currentTheme.solution))
+		for i, p := range path {
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(&content, "%d %d %s\n", p.x*cellWidth+border+halfCellWidth, p.y*cellWidth+border+halfCellWidth, op)
+		}
+		content.WriteString("S\nEMC\n")
+	}
+
+	if label != "" {
+		// Text would come out upside down on the flipped page, so flip
+		// it back.
+		fmt.Fprintf(&content, "BT /F1 10 Tf 1 0 0 -1 %d %d Tm (%s) Tj ET\n", border, border-1, pdfString(label))
+	}
+	content.WriteString("Q\n")
+	boxes := ""
+	if marks.enabled {
+		marks.draw(&content, l.width, l.height)
+		boxes = fmt.Sprintf(" /TrimBox [%.2f %.2f %.2f %.2f] /BleedBox [%.2f %.2f %.2f %.2f]",
+			offset, offset, offset+l.width, offset+l.height,
+			offset-marks.bleed, offset-marks.bleed, offset+l.width+marks.bleed, offset+l.height+marks.bleed)
+	}
+
+	return pdfDocument([]string{
+		"<< /Type /Catalog /Pages 2 0 R /OCProperties << /OCGs [4 0 R] /D << /Order [4 0 R] /OFF [4 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f]%s /Contents 5 0 R "+
+			"/Resources << /Properties << /solution 4 0 R >> /Font << /F1 6 0 R >> >> >>", pageWidth, pageHeight, boxes),
+		"<< /Type /OCG /Name (Solution) >>",
+		pdfStream(content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	})
+}
+
+// Escape text for a PDF string. The standard fonts only cover Latin
+// text, so anything past Latin-1 becomes a question mark.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > 0xff:
+			b.WriteByte('?')
+		case r > '~':
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func pdfStream(data string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(data), data)
+}
+
+// Assemble a PDF from its objects, numbered from 1 in order, with the
+// first being the catalog.
+func pdfDocument(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
@@ -0,0 +1,85 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"syscall/js"
+)
+
+// Normally the frame buffer is RGBA, four bytes a pixel, which JS can
+// read straight out of linear memory. On memory-constrained devices we
+// can instead keep an 8-bit paletted frame buffer, a quarter the size,
+// and convert it to RGBA a strip at a time as we export it. The web
+// safe palette has every color we draw with.
+const palettedFormat = "paletted" // Pixel format argument selecting a paletted frame buffer
+
+const exportStripRows = 64 // Rows converted to RGBA at a time when exporting a paletted frame buffer
+
+// The pixel format requested for the frame buffer.
+var pixelFormat = ""
+
+// Make a new frame buffer in the requested pixel format, from reserved
+// memory if there's enough of it (see memory.go).
+func newFrameBuffer(bounds image.Rectangle) draw.Image {
+	width, height := bounds.Dx(), bounds.Dy()
+	if pixelFormat == palettedFormat {
+		if width*height <= len(reservedPixels) {
+			return &image.Paletted{Pix: reservedPixels[:width*height], Stride: width, Rect: bounds, Palette: palette.WebSafe}
+		}
+		return image.NewPaletted(bounds, palette.WebSafe)
+	}
+	if width*height*4 <= len(reservedPixels) {
+		return &image.RGBA{Pix: reservedPixels[:width*height*4], Stride: width * 4, Rect: bounds}
+	}
+	return image.NewRGBA(bounds)
+}
+
+// Whether the frame buffer is in the requested pixel format.
+func frameBufferMatches() bool {
+	_, paletted := frameBuffer.(*image.Paletted)
+	return paletted == (pixelFormat == palettedFormat)
+}
+
+// Export a paletted frame buffer to the canvas, converting it to RGBA
+// one strip at a time so that there's never a full-size RGBA copy of
+// it in linear memory.
+func exportPaletted(img *image.Paletted) {
+	canvas := targetCanvas
+	ctx := canvas.Call("getContext", "2d")
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if canvas.Get("width").Int() != width || canvas.Get("height").Int() != height {
+		canvas.Set("width", width)
+		canvas.Set("height", height)
+	}
+
+	// We've drawn on the canvas behind putMaze's back, so make sure it
+	// starts over next time.
+	js.Global().Set("lastHeight", js.Undefined())
+
+	var colors [256][4]uint8
+	for i, c := range img.Palette {
+		r, g, b, a := c.RGBA()
+		colors[i] = [4]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+	}
+
+	strip := make([]uint8, width*exportStripRows*4)
+	imageData := ctx.Call("createImageData", width, exportStripRows)
+	for y := 0; y < height; y += exportStripRows {
+		rows := exportStripRows
+		if height-y < rows {
+			rows = height - y
+		}
+
+		for i, index := range img.Pix[y*img.Stride : (y+rows)*img.Stride] {
+			copy(strip[i*4:], colors[index][:])
+		}
+		js.CopyBytesToJS(imageData.Get("data"), strip)
+		ctx.Call("putImageData", imageData, 0, y, 0, 0, width, rows)
+	}
+
+	js.Global().Call("enableExports")
+}
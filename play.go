@@ -0,0 +1,356 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// In playable mode, the player starts at the start of the maze and
+// moves with the arrow keys; walls block them.
+type game struct {
+	m         *maze
+	player    position
+	moves     int
+	distances []int // Steps from each cell to the finish
+
+	started  time.Time
+	ended    time.Time // When the player finished or ran out of moves
+	hints    int       // Number of hints given
+	reported bool      // Whether the solved hook has heard about this game
+
+	checkpoints []position
+	reached     []bool   // Which checkpoints the player has reached
+	respawnAt   position // Where the player goes back to when caught
+
+	limit  int  // Moves allowed, or 0 for no limit
+	failed bool // Whether the player ran out of moves
+
+	world *world // The world being played in, if any
+	room  int    // Which of its rooms the player is in
+
+	shiftEvery int // Moves between wall shifts, or 0 for none
+	shiftWalls int // Walls moved in each shift
+
+	enemies []*enemy
+	rng     *rand.Rand // For wandering enemies
+	last    float64    // Timestamp of the last enemy step, zero if none
+	request js.Value   // Our pending requestAnimationFrame, if any
+	frame   js.Func    // Our requestAnimationFrame callback
+}
+
+// The game being played, if any.
+var currentGame *game = nil
+
+// If set, a JS function called with game events, so that pages can
+// give feedback (e.g. with navigator.vibrate) as the player moves.
+var gameHook js.Value = js.Undefined()
+
+// If set, a JS function called with the game's status after every
+// move, so that pages can show the moves and time so far.
+var moveHook js.Value = js.Undefined()
+
+// Game events.
+const (
+	bumpEvent     = "bump"     // The player walked into a wall
+	junctionEvent = "junction" // The player reached a cell with three or more exits
+	finishEvent   = "finish"   // The player reached the finish
+)
+
+// The player's marker.
+var blue = image.NewUniform(color.RGBA{0, 0, 255, 255})
+
+func newGame(m *maze) *game {
+	return &game{
+		m:         m,
+		player:    m.start,
+		respawnAt: m.start,
+		distances: m.distances(m.finish),
+		started:   time.Now(),
+		request:   js.Undefined(),
+		frame:     js.Func{Value: js.Undefined()},
+	}
+}
+
+// How long the player has been playing, or played for if the game
+// has ended.
+func (g *game) elapsed() time.Duration {
+	if !g.ended.IsZero() {
+		return g.ended.Sub(g.started)
+	}
+	return time.Since(g.started)
+}
+
+// Stop the clock, if it hasn't been already.
+func (g *game) end() {
+	if g.ended.IsZero() {
+		g.ended = time.Now()
+	}
+}
+
+// The game as a MazeGameStatus.
+func (g *game) status() map[string]interface{} {
+	return map[string]interface{}{
+		"at":       positionToJS(g.player),
+		"moves":    g.moves,
+		"time":     float64(g.elapsed().Milliseconds()),
+		"finished": g.finished(),
+		"failed":   g.failed,
+	}
+}
+
+// Steps from the given cell to the finish.
+func (g *game) distance(p position) int {
+	return g.distances[p.y*g.m.width+p.x]
+}
+
+// The directions the player could move in from where they are.
+func (g *game) exits() []direction {
+	var exits []direction
+	for _, d := range []direction{north, east, south, west} {
+		if _, err := d.translate(g.player, g.m); err == nil && g.m.at(g.player).openings[d] {
+			exits = append(exits, d)
+		}
+	}
+	return exits
+}
+
+// Move the player in the given direction, if there isn't a wall in
+// the way. Returns whether they moved.
+func (g *game) move(d direction) bool {
+	np, err := d.translate(g.player, g.m)
+	if err != nil || !g.m.at(g.player).openings[d] {
+		return false
+	}
+
+	g.player = np
+	g.moves++
+	return true
+}
+
+// Tell the game hook about an event at the player's position.
+func (g *game) emit(event string) {
+	if gameHook.Type() == js.TypeFunction {
+		gameHook.Invoke(map[string]interface{}{
+			"type": event,
+			"x":    g.player.x,
+			"y":    g.player.y,
+		})
+	}
+}
+
+// Describe where the player can go from here.
+func (g *game) describeExits() string {
+	exits := g.exits()
+	if len(exits) == 0 {
+		return "No paths."
+	}
+
+	description := "Paths: "
+	for i, d := range exits {
+		if i > 0 {
+			description += ", "
+		}
+		description += d.String()
+	}
+	return description + "."
+}
+
+// Write a short description of what just happened to the page's ARIA
+// live region, so screen readers announce it.
+func (g *game) narrate(d direction, moved bool) {
+	var text string
+	switch {
+	case !moved:
+		text = fmt.Sprintf("Wall to the %s. %s", d, g.describeExits())
+	case g.finished():
+		text = fmt.Sprintf("Moved %s. You reached the finish in %d steps!", d, g.moves)
+	case g.failed:
+		text = fmt.Sprintf("Moved %s. Out of moves!", d)
+	case g.player == g.respawnAt && g.player != g.m.start:
+		text = fmt.Sprintf("Moved %s. Checkpoint. %s %d steps so far.", d, g.describeExits(), g.moves)
+	default:
+		text = fmt.Sprintf("Moved %s. %s %d steps so far.", d, g.describeExits(), g.moves)
+	}
+	announce(text)
+}
+
+func announce(text string) {
+	js.Global().Get("document").Call("getElementById", "narration").Set("textContent", text)
+}
+
+// Draw the player's marker in their cell.
+func (g *game) drawPlayer(img draw.Image) {
+	x := g.player.x*cellWidth + border + cellWidth/4
+	y := g.player.y*cellWidth + border + cellWidth/4
+	draw.Draw(img, image.Rect(x+1, y+1, x+halfCellWidth, y+halfCellWidth), blue, image.Point{0, 0}, draw.Over)
+}
+
+// Scroll the maze's container, smoothly, to keep the player in the
+// middle of it. This only does anything when the maze is bigger than
+// its container.
+func (g *game) follow() {
+	container := js.Global().Get("document").Call("getElementById", "mazeContainer")
+	x := g.player.x*cellWidth + border + halfCellWidth
+	y := g.player.y*cellWidth + border + halfCellWidth
+	container.Call("scrollTo", map[string]interface{}{
+		"left":     float64(x) - container.Get("clientWidth").Float()/2,
+		"top":      float64(y) - container.Get("clientHeight").Float()/2,
+		"behavior": "smooth",
+	})
+}
+
+// Redraw the maze with the player in their new position.
+func (g *game) redraw() {
+	img := g.m.draw()
+	if currentSolution != nil && printing {
+		g.m.drawStyledPath(img, currentSolution, printStyle())
+	} else if currentSolution != nil {
+		g.m.drawPath(img, currentSolution)
+	}
+	g.drawPieces(img)
+	export(currentLabel)
+}
+
+// Take a turn: try to move in the given direction, and give the
+// player all of the feedback that goes with it. Returns whether the
+// player moved.
+func (g *game) play(d direction) bool {
+	if g.failed {
+		announce("Out of moves. Generate a new maze to try again.")
+		return false
+	}
+	from := g.player
+	moved := g.move(d)
+	caught := moved && g.caught(from)
+	switch {
+	case caught:
+		g.respawn()
+	case !moved:
+		g.emit(bumpEvent)
+	case g.finished():
+		g.end()
+		g.emit(finishEvent)
+		g.report(false)
+	case g.checkpoint():
+	case g.outOfMoves():
+	case len(g.exits()) >= 3:
+		g.emit(junctionEvent)
+	}
+	teleported := moved && !caught && g.teleport()
+	if moved && !teleported {
+		cells := []position{from, g.player}
+		if g.shiftEvery > 0 && g.moves%g.shiftEvery == 0 {
+			cells = append(cells, g.m.shiftWalls(g.shiftWalls)...)
+			g.distances = g.m.distances(g.m.finish)
+		}
+		g.redrawCells(cells)
+		g.follow()
+	}
+	if !caught && !teleported {
+		g.narrate(d, moved)
+	}
+	if sonifying() {
+		g.sonify(moved)
+	}
+	if moved && moveHook.Type() == js.TypeFunction {
+		moveHook.Invoke(g.status())
+	}
+	return moved
+}
+
+func gameStatusJS(this js.Value, args []js.Value) interface{} {
+	if currentGame == nil {
+		return js.Null()
+	}
+	return currentGame.status()
+}
+
+func onMoveJS(this js.Value, args []js.Value) interface{} {
+	moveHook = js.Undefined()
+	if len(args) > 0 && args[0].Type() == js.TypeFunction {
+		moveHook = args[0]
+	}
+	return nil
+}
+
+// Build the JS-facing game controls.
+func gameControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	onEvent := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		gameHook = js.Undefined()
+		if len(args) > 0 {
+			gameHook = args[0]
+		}
+		return nil
+	})
+	controls.Set("onEvent", onEvent)
+
+	move := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if currentGame == nil || len(args) == 0 {
+			return false
+		}
+		d, ok := directionWords[strings.ToLower(args[0].String())]
+		return ok && currentGame.play(d)
+	})
+	controls.Set("move", move)
+
+	command := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if currentGame == nil || len(args) == 0 {
+			return 0
+		}
+		return currentGame.command(args[0].String())
+	})
+	controls.Set("command", command)
+
+	onSolved := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		solvedHook = js.Undefined()
+		if len(args) > 0 {
+			solvedHook = args[0]
+		}
+		return nil
+	})
+	controls.Set("onSolved", onSolved)
+
+	hint := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if currentGame == nil {
+			return js.Null()
+		}
+		d, ok := currentGame.hint()
+		if !ok {
+			return js.Null()
+		}
+		return d.String()
+	})
+	controls.Set("hint", hint)
+
+	save := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if currentGame == nil {
+			return js.Null()
+		}
+		return currentGame.save()
+	})
+	controls.Set("save", save)
+
+	restore := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if currentGame == nil || len(args) == 0 {
+			return false
+		}
+		if err := currentGame.restore(args[0]); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return false
+		}
+		return true
+	})
+	controls.Set("restore", restore)
+
+	return controls, []js.Func{onEvent, move, command, onSolved, hint, save, restore}
+}
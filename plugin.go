@@ -0,0 +1,103 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// Custom renderers let pages (or other Go code) try out new ways of
+// drawing mazes without touching drawCell. A renderer is chosen by
+// name with the renderer setting, just like the built-in ones, and is
+// handed the maze to draw on the target canvas.
+
+// A custom renderer: draw the maze, its solution (if any), and its
+// label on the target canvas.
+type renderer func(m *maze, path []position, label string)
+
+// Custom renderers by name.
+var renderers = make(map[string]renderer)
+
+// Register a custom renderer under the given name, replacing any
+// renderer already there; a nil renderer removes it.
+func registerRenderer(name string, r renderer) {
+	if r == nil {
+		delete(renderers, name)
+		return
+	}
+	renderers[name] = r
+}
+
+// The maze as plain data for JS renderers. Each cell's walls are a bit
+// mask of its openings: 1 north, 2 south, 4 east, 8 west.
+func mazeToJS(m *maze, path []position, label string) js.Value {
+	cells := make([]byte, len(m.cells))
+	for i, c := range m.cells {
+		for d, open := range c.openings {
+			if open {
+				cells[i] |= 1 << d
+			}
+		}
+	}
+
+	width, height := m.imageSize()
+	data := js.Global().Get("Object").New()
+	data.Set("width", m.width)
+	data.Set("height", m.height)
+	data.Set("imageWidth", width)
+	data.Set("imageHeight", height)
+	data.Set("cells", bytesToJS(cells))
+	data.Set("start", positionToJS(m.start))
+	data.Set("finish", positionToJS(m.finish))
+	data.Set("solution", js.Null())
+	if path != nil {
+		data.Set("solution", pathToJS(path))
+	}
+	data.Set("label", label)
+	return data
+}
+
+// Wrap a JS function as a renderer. It's called with the maze data and
+// the canvas, and either draws on the canvas itself and returns
+// nothing, or returns ImageData (or RGBA pixels of the image size),
+// which we put on the canvas and label.
+func jsRenderer(fn js.Value) renderer {
+	return func(m *maze, path []position, label string) {
+		defer tr(ace("running custom renderer"))
+
+		result := fn.Invoke(mazeToJS(m, path, label), targetCanvas)
+		if result.IsUndefined() || result.IsNull() {
+			js.Global().Call("enableExports")
+			return
+		}
+
+		width, height := m.imageSize()
+		if !result.InstanceOf(js.Global().Get("ImageData")) {
+			pixels := js.Global().Get("Uint8ClampedArray").New(result)
+			result = js.Global().Get("ImageData").New(pixels, width, height)
+		}
+		targetCanvas.Set("width", result.Get("width"))
+		targetCanvas.Set("height", result.Get("height"))
+
+		// We've resized the canvas behind putMaze's back.
+		js.Global().Set("lastHeight", js.Undefined())
+
+		ctx := targetCanvas.Call("getContext", "2d")
+		ctx.Call("putImageData", result, 0, 0)
+		drawLabel(ctx, label, width, height, currentLabelStyle)
+		js.Global().Call("enableExports")
+	}
+}
+
+func registerRendererJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return nil
+	}
+	if len(args) < 2 || args[1].Type() != js.TypeFunction {
+		registerRenderer(args[0].String(), nil)
+		return nil
+	}
+	registerRenderer(args[0].String(), jsRenderer(args[1]))
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// PNG exports carry the maze's parameters in tEXt chunks, so that the
+// image file alone is enough to generate the same maze again: reading
+// the chunks back gives its ID (see mazeID) and the settings that
+// reshaped it after generation.
+
+var noParameters = errors.New("png has no maze parameters")
+
+// PNG text keywords for each parameter.
+const (
+	pngKeyID        = "Maze ID"
+	pngKeyAlgorithm = "Maze Algorithm"
+	pngKeyDiff      = "Maze Difficulty"
+	pngKeyDecoys    = "Maze Decoys"
+	pngKeySolutions = "Maze Solutions"
+	pngKeyBraid     = "Maze Braid"
+)
+
+// The parameters to write into a PNG of the given maze, which has the
+// given ID and was reshaped with the given settings.
+func mazeParameters(m *maze, id string, decoys, solutions, braid int) [][2]string {
+	return [][2]string{
+		{"Software", "twistylittlepassages"},
+		{pngKeyID, id},
+		{pngKeyAlgorithm, m.algorithm},
+		{pngKeyDiff, strconv.Itoa(m.difficulty(m.solution()))},
+		{pngKeyDecoys, strconv.Itoa(decoys)},
+		{pngKeySolutions, strconv.Itoa(solutions)},
+		{pngKeyBraid, strconv.Itoa(braid)},
+	}
+}
+
+// Insert tEXt chunks with the given keywords and text into a PNG,
+// just after its header.
+func addPNGText(data []byte, text [][2]string) ([]byte, error) {
+	const headerEnd = 8 + 4 + 4 + 13 + 4 // Signature, then IHDR's length, type, data, and CRC
+	if len(data) < headerEnd || !bytes.HasPrefix(data, pngSignature) {
+		return nil, badPNG
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:headerEnd])
+	for _, t := range text {
+		writeChunk(&out, "tEXt", []byte(t[0]+"\x00"+t[1]))
+	}
+	out.Write(data[headerEnd:])
+	return out.Bytes(), nil
+}
+
+// Read the tEXt chunks of a PNG, by keyword.
+func pngText(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, badPNG
+	}
+
+	text := map[string]string{}
+	for i := len(pngSignature); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		if i+12+length > len(data) {
+			return nil, badPNG
+		}
+		kind, body := string(data[i+4:i+8]), data[i+8:i+8+length]
+		if kind == "tEXt" {
+			if k := bytes.IndexByte(body, 0); k >= 0 {
+				text[string(body[:k])] = string(body[k+1:])
+			}
+		}
+		if kind == "IEND" {
+			break
+		}
+		i += 12 + length
+	}
+	return text, nil
+}
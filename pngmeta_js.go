@@ -0,0 +1,118 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"syscall/js"
+)
+
+// Settings that changed the current maze after it was generated, which
+// its ID doesn't capture.
+var currentShaping struct {
+	decoys, solutions int
+	braid             int
+}
+
+// Copy a Uint8Array or ArrayBuffer out of JS.
+func bytesFromJS(v js.Value) []byte {
+	if !v.InstanceOf(js.Global().Get("Uint8Array")) {
+		v = js.Global().Get("Uint8Array").New(v)
+	}
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+// The parameters to write into a PNG of the current maze. They carry
+// the ID it was generated with even if it's been reshaped since, and so
+// has no ID of its own, since they say how it was reshaped.
+func currentParameters() [][2]string {
+	id := mazeID(currentMaze.height, currentMaze.width, currentSeed, currentMaze.oppositeStart, currentMaze.algorithm)
+	return mazeParameters(currentMaze, id, currentShaping.decoys, currentShaping.solutions, currentShaping.braid)
+}
+
+// Export what's on the canvas as a PNG, with the maze's parameters.
+func exportPNGCallback() {
+	if !exportable() || glShown {
+		return
+	}
+	parameters := currentParameters()
+
+	var saved, read js.Func
+	read = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer read.Release()
+		data, err := addPNGText(bytesFromJS(args[0]), parameters)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return nil
+		}
+		offerDownload.Invoke("maze.png", "image/png", bytesToJS(data))
+		return nil
+	})
+	saved = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer saved.Release()
+		args[0].Call("arrayBuffer").Call("then", read)
+		return nil
+	})
+	targetCanvas.Call("toBlob", saved, "image/png")
+}
+
+// readPNG(bytes) returns the maze parameters in a PNG, or null if it
+// has none.
+func readPNGJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 {
+		return js.Null()
+	}
+	text, err := pngText(bytesFromJS(args[0]))
+	if err == nil && text[pngKeyID] == "" {
+		err = noParameters
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+
+	info := js.Global().Get("Object").New()
+	info.Set("id", text[pngKeyID])
+	info.Set("algorithm", text[pngKeyAlgorithm])
+	for key, name := range map[string]string{pngKeyDiff: "difficulty", pngKeyDecoys: "decoys", pngKeySolutions: "solutions", pngKeyBraid: "braid"} {
+		n, _ := strconv.Atoi(text[key])
+		info.Set(name, n)
+	}
+	return info
+}
+
+// loadPNG(bytes) sets the page's settings from the parameters in a
+// PNG and generates the maze it shows. Returns whether it could.
+func loadPNGJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 {
+		return false
+	}
+	text, err := pngText(bytesFromJS(args[0]))
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return false
+	}
+	height, width, seed, oppositeStart, algorithm, err := parseMazeID(text[pngKeyID])
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return false
+	}
+
+	document := js.Global().Get("document")
+	setDimension("mazeHeight", height)
+	setDimension("mazeWidth", width)
+	document.Call("getElementById", "randomSeed").Set("value", strconv.FormatInt(seed, 10))
+	document.Call("getElementById", "oppositeStart").Set("checked", oppositeStart)
+	document.Call("getElementById", "algorithm").Set("value", algorithm)
+	for key, id := range map[string]string{pngKeyDecoys: "decoys", pngKeySolutions: "targetSolutions", pngKeyBraid: "braid"} {
+		if n, err := strconv.Atoi(text[key]); err == nil {
+			document.Call("getElementById", id).Set("value", n)
+		}
+	}
+	generateCallback()
+	return true
+}
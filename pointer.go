@@ -0,0 +1,125 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// Pages can follow the pointer over the maze cell by cell, for
+// tooltips, editors, or clicking to choose a start, without working
+// out the maze's geometry themselves: we turn pointer events on the
+// canvas into cells and hand them to a callback, with the cell's walls.
+// Hovering only calls back when the pointer moves onto another cell.
+
+const (
+	hoverEvent = "hover" // The pointer moved onto a cell
+	clickEvent = "click" // A cell was clicked
+	leaveEvent = "leave" // The pointer left the maze's cells
+)
+
+// If set, a JS function called with cell events.
+var cellHook js.Value = js.Undefined()
+
+// The cell the pointer is over, if it's over one.
+var (
+	hovering = false
+	hovered  position
+)
+
+// The point of the maze's image (in pixels) under a pointer event on
+// the given canvas. The WebGL canvas shows whatever part of the image
+// its view is panned and zoomed to, as does the raster canvas for
+// mazes drawn through a viewport; otherwise canvases show all of it,
+// however they're sized.
+func imagePoint(canvas, event js.Value) (x, y float64) {
+	rect := canvas.Call("getBoundingClientRect")
+	fx := (event.Get("clientX").Float() - rect.Get("left").Float()) / rect.Get("width").Float()
+	fy := (event.Get("clientY").Float() - rect.Get("top").Float()) / rect.Get("height").Float()
+
+	if glView != nil && canvas.Equal(glView.canvas) {
+		return glView.panX + fx*glView.width/glView.zoom, glView.panY + fy*glView.height/glView.zoom
+	}
+	if currentView != nil && canvas.Equal(targetCanvas) {
+		return currentView.x + fx*float64(currentView.width)/currentView.zoom, currentView.y + fy*float64(currentView.height)/currentView.zoom
+	}
+	width, height := currentMaze.imageSize()
+	return fx * float64(width), fy * float64(height)
+}
+
+func cellEventToJS(kind string, p position) js.Value {
+	v := js.Global().Get("Object").New()
+	v.Set("type", kind)
+	if kind == leaveEvent {
+		return v
+	}
+
+	c := currentMaze.at(p)
+	walls := js.Global().Get("Object").New()
+	for _, d := range []direction{north, south, east, west} {
+		walls.Set(d.String(), !c.openings[d])
+	}
+	v.Set("cell", positionToJS(p))
+	v.Set("walls", walls)
+	v.Set("start", p == currentMaze.start)
+	v.Set("finish", p == currentMaze.finish)
+	return v
+}
+
+// Handle a pointer event of the given kind on the given canvas.
+func cellCallback(canvas js.Value, kind string) func(js.Value) {
+	return func(event js.Value) {
+		if cellHook.Type() != js.TypeFunction || currentMaze == nil {
+			hovering = false
+			return
+		}
+
+		p, ok := position{}, false
+		if kind != leaveEvent {
+			p, ok = currentMaze.cellAt(imagePoint(canvas, event))
+		}
+		switch {
+		case kind == clickEvent && ok:
+			cellHook.Invoke(cellEventToJS(clickEvent, p))
+		case kind == hoverEvent && ok && (!hovering || p != hovered):
+			hovering, hovered = true, p
+			cellHook.Invoke(cellEventToJS(hoverEvent, p))
+		case kind != clickEvent && !ok && hovering:
+			hovering = false
+			cellHook.Invoke(cellEventToJS(leaveEvent, p))
+		}
+	}
+}
+
+// Call fn with the given pointer event on the element, until we're
+// disposed of.
+func listenPointer(element js.Value, event string, fn func(js.Value)) {
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn(args[0])
+		return nil
+	})
+
+	element.Call("addEventListener", event, cb)
+	attached(func() {
+		element.Call("removeEventListener", event, cb)
+		cb.Release()
+	})
+}
+
+// Report cell events on the given canvas.
+func listenCells(canvas js.Value) {
+	listenPointer(canvas, "pointermove", cellCallback(canvas, hoverEvent))
+	listenPointer(canvas, "click", cellCallback(canvas, clickEvent))
+	listenPointer(canvas, "pointerleave", cellCallback(canvas, leaveEvent))
+}
+
+// onCell(fn) calls fn with cell events as the pointer moves over and
+// clicks the maze; null stops it.
+func onCellJS(this js.Value, args []js.Value) interface{} {
+	cellHook, hovering = js.Undefined(), false
+	if len(args) > 0 && args[0].Type() == js.TypeFunction {
+		cellHook = args[0]
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Poster mode splits a maze too big for one sheet across several, for
+// printing wall-sized mazes on an ordinary printer. Each page shows its
+// piece of the maze, overlapping the next page's by a margin so pages
+// can be trimmed and taped together, with crop marks at the corners of
+// its piece, a dashed line where the overlap starts, and a label (A1,
+// A2, ... B1, ...) naming its row and column. The pages are an HTML
+// document, sized with @page, to print from the browser.
+
+const (
+	posterMargin  = 12.0 // Margin around each page's piece, for the printer and labels (in mm)
+	posterOverlap = 10.0 // Overlap between neighbouring pages (in mm)
+	cropMark      = 6.0  // Length of crop marks (in mm)
+)
+
+var unknownPaper = errors.New("unknown paper size")
+
+// Paper sizes, width by height (in mm).
+var paperSizes = map[string][2]float64{
+	"letter": {215.9, 279.4},
+	"a4":     {210, 297},
+}
+
+// The name of the given row of pages: A to Z, then AA, AB, and so on.
+func rowName(row int) string {
+	name := ""
+	for row++; row > 0; row = (row - 1) / 26 {
+		name = string(rune('A'+(row-1)%26)) + name
+	}
+	return name
+}
+
+// Render the maze as a poster on the given paper, with cells of the
+// given size (in mm), and the solution if path is not nil.
+func (m *maze) posterHTML(path []position, style, paper string, cellSize float64) (string, error) {
+	defer tr(ace("rendering poster"))
+
+	size, ok := paperSizes[paper]
+	if !ok {
+		return "", unknownPaper
+	}
+	pageWidth, pageHeight := size[0], size[1]
+	width, height := m.imageSize()
+	scale := cellSize / float64(cellWidth)
+	posterWidth, posterHeight := float64(width)*scale, float64(height)*scale
+
+	pieceWidth, pieceHeight := pageWidth-posterMargin*2, pageHeight-posterMargin*2
+	stepX, stepY := pieceWidth-posterOverlap, pieceHeight-posterOverlap
+	columns := int(math.Max(1, math.Ceil((posterWidth-posterOverlap)/stepX)))
+	rows := int(math.Max(1, math.Ceil((posterHeight-posterOverlap)/stepY)))
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Maze poster</title>\n<style>\n")
+	fmt.Fprintf(&b, "@page { size: %gmm %gmm; margin: 0; }\n", pageWidth, pageHeight)
+	fmt.Fprintf(&b, "body { margin: 0; }\n.page { width: %gmm; height: %gmm; overflow: hidden; break-after: page; }\n", pageWidth, pageHeight)
+	b.WriteString("</style>\n</head>\n<body>\n")
+	b.WriteString(`<svg width="0" height="0" style="position: absolute"><defs><g id="maze">` + "\n")
+	b.WriteString(m.svgContent(path, style, 1))
+	b.WriteString("</g></defs></svg>\n")
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			label := fmt.Sprintf("%s%d", rowName(row), column+1)
+			left, top := posterMargin, posterMargin
+			right, bottom := left+pieceWidth, top+pieceHeight
+
+			fmt.Fprintf(&b, `<div class="page"><svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g">`+"\n", pageWidth, pageHeight, pageWidth, pageHeight)
+			fmt.Fprintf(&b, `<clipPath id="piece%s"><rect x="%g" y="%g" width="%g" height="%g"/></clipPath>`+"\n", label, left, top, pieceWidth, pieceHeight)
+			fmt.Fprintf(&b, `<g clip-path="url(#piece%s)"><use href="#maze" transform="translate(%.3f %.3f) scale(%g)"/></g>`+"\n",
+				label, left-float64(column)*stepX, top-float64(row)*stepY, scale)
+
+			// Crop marks, out in the margin so they don't cover the maze.
+			b.WriteString(`<g stroke="black" stroke-width="0.2">`)
+			for _, corner := range [][2]float64{{left, top}, {right, top}, {left, bottom}, {right, bottom}} {
+				dx, dy := -1.0, -1.0
+				if corner[0] == right {
+					dx = 1
+				}
+				if corner[1] == bottom {
+					dy = 1
+				}
+				x, y := corner[0], corner[1]
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`, x+dx, y, x+dx*cropMark, y)
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`, x, y+dy, x, y+dy*cropMark)
+			}
+			b.WriteString("</g>\n")
+
+			// Where the next pages' pieces start.
+			b.WriteString(`<g stroke="gray" stroke-width="0.2" stroke-dasharray="2 2">`)
+			if column+1 < columns {
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`, right-posterOverlap, top, right-posterOverlap, bottom)
+			}
+			if row+1 < rows {
+				fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g"/>`, left, bottom-posterOverlap, right, bottom-posterOverlap)
+			}
+			b.WriteString("</g>\n")
+
+			fmt.Fprintf(&b, `<text x="%g" y="%g" font-family="sans-serif" font-size="4">%s (row %s of %s, column %d of %d). Trim and overlap the dashed edges with the next pages.</text>`+"\n",
+				left, top-cropMark/2, label, rowName(row), rowName(rows-1), column+1, columns)
+			b.WriteString("</svg></div>\n")
+		}
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String(), nil
+}
@@ -0,0 +1,30 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// Export the current maze as a poster, with the paper and cell size
+// chosen on the page.
+func exportPosterCallback() {
+	if !exportable() {
+		return
+	}
+	document := js.Global().Get("document")
+	paper := document.Call("getElementById", "posterPaper").Get("value").String()
+	cellSize := document.Call("getElementById", "posterCell").Get("valueAsNumber").Float()
+	if !(cellSize > 0) {
+		return
+	}
+
+	html, err := currentMaze.posterHTML(currentSolution, printStyle(), paper, cellSize)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("maze-poster.html", "text/html", html)
+}
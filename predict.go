@@ -0,0 +1,98 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"math/rand"
+	"syscall/js"
+	"time"
+)
+
+// Generating and drawing a maze takes time roughly proportional to its
+// number of cells, plus some fixed overhead, but the constants vary a
+// lot between devices. The first time we're asked for a prediction, we
+// time a small and a medium maze with each algorithm and fit a line
+// through them.
+
+var calibrationSizes = [2]int{20, 80} // Heights and widths (in cells) of the calibration mazes
+
+// Fixed (in milliseconds) and per-cell costs of an algorithm.
+type timing struct {
+	fixed, perCell float64
+}
+
+// Calibrated timings, by algorithm name, once we've made them.
+var timings map[string]timing = nil
+
+// How long it takes to generate and draw a maze of the given size with
+// the given generator, in milliseconds.
+func measure(generate func(m *maze), size int) float64 {
+	m := newMaze(size, size, rand.New(rand.NewSource(1)), false)
+	width, height := m.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	start := time.Now()
+	generate(m)
+	m.drawOnto(img)
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
+
+func calibrate() {
+	defer tr(ace("calibrating timings"))
+
+	timings = make(map[string]timing)
+	small, large := calibrationSizes[0], calibrationSizes[1]
+	for _, g := range generators {
+		measure(g.generate, small) // Warm up
+		a, b := measure(g.generate, small), measure(g.generate, large)
+		perCell := (b - a) / float64(large*large-small*small)
+		if perCell < 0 {
+			perCell = 0
+		}
+		fixed := a - perCell*float64(small*small)
+		if fixed < 0 {
+			fixed = 0
+		}
+		timings[g.name] = timing{fixed, perCell}
+	}
+}
+
+// Predict how long generating and drawing a maze will take, in
+// milliseconds. An unknown algorithm is taken to be the default one.
+func predict(height, width int, algorithm string) float64 {
+	if timings == nil {
+		calibrate()
+	}
+	t, ok := timings[algorithm]
+	if !ok {
+		t = timings[generatorAlgorithm]
+	}
+	return t.fixed + t.perCell*float64(height*width)
+}
+
+func predictJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return js.Null()
+	}
+	params := args[0]
+	if params.Get("height").Type() != js.TypeNumber || params.Get("width").Type() != js.TypeNumber {
+		return js.Null()
+	}
+	algorithm := generatorAlgorithm
+	if a := params.Get("algorithm"); a.Type() == js.TypeString {
+		algorithm = a.String()
+	}
+	return predict(params.Get("height").Int(), params.Get("width").Int(), algorithm)
+}
+
+// Build the JS-facing prediction API. predict({height, width,
+// algorithm}) returns the estimated time in milliseconds; algorithm is
+// optional.
+func predictControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	predictFunc := js.FuncOf(predictJS)
+	controls.Set("predict", predictFunc)
+	return controls, []js.Func{predictFunc}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Solution styles. On screen the solution is always solid red, but red
+// photocopies as a faint gray, so printed output can use a black
+// dashed or dotted line instead. Anything else means solid red.
+const (
+	dashedStyle = "dashed"
+	dottedStyle = "dotted"
+)
+
+// Dash patterns (in pixels drawn, then pixels skipped) for each style.
+var dashPatterns = map[string][2]int{
+	dashedStyle: {4, 3},
+	dottedStyle: {1, 2},
+}
+
+// SVG stroke attributes for each style.
+var svgPathStyles = map[string]string{
+	dashedStyle: `stroke="black" stroke-dasharray="4 3"`,
+	dottedStyle: `stroke="black" stroke-dasharray="0 3" stroke-linecap="round" stroke-width="1.5"`,
+}
+
+// Draw the solution path in the given style. The dash pattern runs
+// continuously along the path rather than restarting at every cell.
+func (m *maze) drawStyledPath(img draw.Image, path []position, style string) {
+	pattern, ok := dashPatterns[style]
+	if !ok {
+		m.drawPath(img, path)
+		return
+	}
+	defer tr(ace("drawing styled solution"))
+
+	phase := 0
+	for i := 1; i < len(path); i++ {
+		x0, y0 := path[i-1].x*cellWidth+border+halfCellWidth, path[i-1].y*cellWidth+border+halfCellWidth
+		x1, y1 := path[i].x*cellWidth+border+halfCellWidth, path[i].y*cellWidth+border+halfCellWidth
+		dx, dy := sign(x1-x0), sign(y1-y0)
+		for x, y := x0, y0; x != x1 || y != y1; x, y = x+dx, y+dy {
+			if phase < pattern[0] {
+				img.Set(x, y, image.Black)
+			}
+			phase = (phase + 1) % (pattern[0] + pattern[1])
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// Whether the browser is printing the page; while it is, the canvas
+// shows the print style.
+var printing = false
+
+// The print style currently selected.
+func printStyle() string {
+	return pageSettings.value("printStyle")
+}
+
+// Redraw the current maze for printing, and then back again after.
+func printCallback(starting bool) {
+	printing = starting
+	redrawCurrent()
+}
+
+// Call fn when the window fires the given event, until we're disposed
+// of.
+func listenWindow(event string, fn func()) {
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn()
+		return nil
+	})
+
+	js.Global().Call("addEventListener", event, cb)
+	attached(func() {
+		js.Global().Call("removeEventListener", event, cb)
+		cb.Release()
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import "image/draw"
+
+// Generation can be watched: the maze grows from a grid of walls,
+// knocked down in the order the algorithm carved them, whichever
+// algorithm it was. Then, if the solution is wanted, the solver's
+// depth-first search is shown, cells shaded as it pushes them onto
+// its stack and grayed as it gives up on them and pops them off.
+
+// A wall knocked down by carve.
+type carving struct {
+	p   position
+	dir direction
+}
+
+// A step of the solver's search.
+type searchStep struct {
+	p      position
+	popped bool // Whether p was popped off the stack, rather than pushed
+}
+
+// Generate the maze with the named algorithm, recording the walls it
+// carves in m.carvings.
+func (m *maze) generateRecorded(name string) {
+	m.carvings = []carving{}
+	m.recording = true
+	m.generateWith(name)
+	m.recording = false
+}
+
+// Erase the wall on the given side of a cell, leaving the corners.
+// Lines reach past their ends, so the erasing line stops a line's
+// width short of each corner.
+func (m *maze) eraseWall(img draw.Image, p position, d direction) {
+	x0, y0 := p.x*cellWidth+border, p.y*cellWidth+border
+	x1, y1 := x0+cellWidth, y0+cellWidth
+	switch d {
+	case north:
+		hLine(img, x0+lineWidth, y0, x1-lineWidth, background)
+	case south:
+		hLine(img, x0+lineWidth, y1, x1-lineWidth, background)
+	case west:
+		vLine(img, x0, y0+lineWidth, y1-lineWidth, background)
+	case east:
+		vLine(img, x1, y0+lineWidth, y1-lineWidth, background)
+	}
+}
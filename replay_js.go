@@ -0,0 +1,55 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image/draw"
+)
+
+// Build an animation growing the maze as it was carved, over the
+// given duration. If path is given, the solver's search for it is
+// shown once the maze is finished, and then the path itself.
+func (m *maze) growth(path []position, duration float64, render func()) *animation {
+	var searched []searchStep
+	if path != nil {
+		m.search(func(s searchStep) {
+			searched = append(searched, s)
+		})
+	}
+
+	// The maze before anything was carved.
+	walls := &maze{height: m.height, width: m.width, cells: make([]cell, len(m.cells))}
+
+	carved := len(m.carvings)
+	steps := carved + 1
+	if path != nil {
+		steps += len(searched) + 1
+	}
+
+	var img draw.Image
+	return newAnimation(steps, duration,
+		func() { img = walls.draw() },
+		func(i int) {
+			switch {
+			case i < carved:
+				c := m.carvings[i]
+				m.eraseWall(img, c.p, c.dir)
+			case i == carved:
+				// The finished maze, with its entrance and exit and
+				// whatever reshaped it after generation.
+				img = m.draw()
+			case i < steps-1:
+				s := searched[i-carved-1]
+				col := stackColor
+				if s.popped {
+					col = filledColor
+				}
+				m.fillCell(img, s.p, col)
+			default:
+				m.drawPath(img, path)
+			}
+		},
+		render,
+	)
+}
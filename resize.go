@@ -0,0 +1,231 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// Ways of fitting the maze to its container as the container resizes.
+// Anything else leaves the maze alone.
+const (
+	fitScale      = "scale"      // Redraw the current maze to fill the container's width
+	fitRegenerate = "regenerate" // Generate a new maze as many cells wide as will fit
+	fitFill       = "fill"       // Generate a new maze as many cells wide and high as will fill the container
+)
+
+const regenerateDelay = 250 // Milliseconds to wait for resizing to settle before regenerating
+
+// Run a function once calls to trigger have stopped for a while;
+// every call pushes it back. Timers run on the JS side, so fn is
+// called from the event loop just like any other callback.
+type debouncer struct {
+	timeout js.Value
+	fn      js.Func
+}
+
+func newDebouncer(fn func()) *debouncer {
+	d := &debouncer{}
+	d.fn = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		d.timeout = js.Undefined()
+		fn()
+		return nil
+	})
+	return d
+}
+
+func (d *debouncer) trigger(delay float64) {
+	d.cancel()
+	d.timeout = js.Global().Call("setTimeout", d.fn, delay)
+}
+
+func (d *debouncer) cancel() {
+	if !d.timeout.IsUndefined() {
+		js.Global().Call("clearTimeout", d.timeout)
+		d.timeout = js.Undefined()
+	}
+}
+
+// Cancel the debouncer for good, and release its callback.
+func (d *debouncer) release() {
+	d.cancel()
+	d.fn.Release()
+}
+
+var regenerate = newDebouncer(func() {
+	stopAnimation()
+	setDimension("mazeWidth", fitWidth())
+	generateCallback()
+})
+
+// The fit mode, or none if the page has no container to fit the maze
+// to.
+func fitMode() string {
+	if element("mazeContainer").IsNull() {
+		return ""
+	}
+	return pageSettings.value("fitMode")
+}
+
+// The container's width, or 0 if there's no container.
+func containerWidth() float64 {
+	container := element("mazeContainer")
+	if container.IsNull() {
+		return 0
+	}
+	return container.Get("clientWidth").Float()
+}
+
+// The height the container can grow to: its maximum height if it has
+// one, otherwise the window's.
+func containerHeight() float64 {
+	container := element("mazeContainer")
+	if container.IsNull() {
+		return js.Global().Get("innerHeight").Float()
+	}
+	if h := js.Global().Call("getComputedStyle", container).Get("maxHeight").String(); h != "none" {
+		if px := js.Global().Call("parseFloat", h).Float(); px > 0 {
+			return px
+		}
+	}
+	return js.Global().Get("innerHeight").Float()
+}
+
+// The cell size (in CSS pixels) the fill fit mode aims for.
+func fillCell() float64 {
+	cell := pageSettings.number("fitCell")
+	if !(cell >= 1) {
+		return float64(cellWidth)
+	}
+	return cell
+}
+
+// The number of cells down and across, at the fill cell size, that
+// fill the container. The border scales along with the cells.
+func fillDimensions() (height, width int) {
+	cell := fillCell()
+	edge := float64(border*2) * cell / float64(cellWidth)
+	clamp := func(n float64) int {
+		switch {
+		case n < 2:
+			return 2
+		case n > float64(maxDrawnCells()):
+			return maxDrawnCells()
+		}
+		return int(n)
+	}
+	return clamp((containerHeight() - edge) / cell), clamp((containerWidth() - edge) / cell)
+}
+
+// The zoom at which the maze's image fills as much of the container as
+// it can without scrolling, or 1 if there's nothing to fill. Mazes of
+// the fill dimensions come out within a cell's width of filling it in
+// both directions, and this takes up the rest.
+func fillZoom(m *maze) float64 {
+	width, height := m.imageSize()
+	zoom := containerWidth() / float64(width)
+	if z := containerHeight() / float64(height); z < zoom {
+		zoom = z
+	}
+	if zoom > 0 {
+		return zoom
+	}
+	return 1
+}
+
+// The zoom at which the maze's image exactly fills the container's
+// width, or 1 if the container has no width to fill.
+func fitZoom(m *maze) float64 {
+	width, _ := m.imageSize()
+	if zoom := containerWidth() / float64(width); zoom > 0 {
+		return zoom
+	}
+	return 1
+}
+
+// The number of cells across that fit in the container at normal size.
+func fitWidth() int {
+	width := (int(containerWidth()) - border*2) / cellWidth
+	if width < 2 {
+		width = 2
+	}
+	if width > maxDrawnCells() {
+		width = maxDrawnCells()
+	}
+	return width
+}
+
+// Set a property of the input with the given ID, if the page has it,
+// returning the input.
+func setInput(id, property string, value interface{}) js.Value {
+	input := element(id)
+	if !input.IsNull() {
+		input.Set(property, value)
+	}
+	return input
+}
+
+// Set one of the dimension sliders, and the output that shows it.
+func setDimension(id string, value int) {
+	input := setInput(id, "value", value)
+	if input.IsNull() {
+		return
+	}
+	if output := input.Get("nextElementSibling"); !output.IsNull() {
+		output.Set("value", value)
+	}
+}
+
+// Called whenever the maze's container changes size.
+func resizeCallback() {
+	switch fitMode() {
+	case fitScale:
+		if currentMaze != nil {
+			stopAnimation()
+			renderCanvas(currentMaze, currentSolution, currentLabel, fitZoom(currentMaze), false, 0)
+		}
+	case fitRegenerate:
+		if fitWidth() != currentWidth() {
+			regenerate.trigger(regenerateDelay)
+		}
+	case fitFill:
+		if currentMaze == nil {
+			return
+		}
+		if height, width := fillDimensions(); height != currentMaze.height || width != currentMaze.width {
+			regenerate.trigger(regenerateDelay)
+			return
+		}
+		stopAnimation()
+		renderCanvas(currentMaze, currentSolution, currentLabel, fillZoom(currentMaze), false, 0)
+	}
+}
+
+func currentWidth() int {
+	if currentMaze == nil {
+		return 0
+	}
+	return currentMaze.width
+}
+
+// Call fn whenever the element with the given ID changes size, until
+// we're disposed of.
+func observeResize(id string, fn func()) {
+	element := element(id)
+	if element.IsNull() {
+		return
+	}
+
+	cb := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn()
+		return nil
+	})
+
+	observer := js.Global().Get("ResizeObserver").New(cb)
+	observer.Call("observe", element)
+	attached(func() {
+		observer.Call("disconnect")
+		cb.Release()
+	})
+}
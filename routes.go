@@ -0,0 +1,172 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// A perfect maze has exactly one route from start to finish, but once
+// it has loops there can be several. These find and draw the k shortest
+// routes that differ in at least one step, so that puzzle authors can
+// see how many real choices a braided maze offers.
+
+// Colors for each route, shortest first.
+var routeColors = []image.Image{
+	solutionColor,
+	image.NewUniform(color.RGBA{0, 0, 255, 255}),
+	image.NewUniform(color.RGBA{0, 160, 0, 255}),
+	image.NewUniform(color.RGBA{255, 140, 0, 255}),
+	image.NewUniform(color.RGBA{160, 0, 160, 255}),
+	image.NewUniform(color.RGBA{0, 160, 160, 255}),
+}
+
+const maxRoutes = 6 // Most routes we'll look for; one per color
+
+// A step between two adjacent cells.
+type step [2]position
+
+// Find a shortest path between two cells via breadth-first search,
+// avoiding the given steps and any cells marked blocked (which is
+// indexed like m.cells).
+func (m *maze) shortestAvoiding(from, to position, blocked []bool, steps map[step]bool) []position {
+	// parent[i] is the cell we reached cell i from, plus one, so that
+	// zero means unvisited.
+	parent := make([]int32, len(m.cells))
+	index := func(p position) int { return p.y*m.width + p.x }
+	parent[index(from)] = int32(index(from)) + 1
+
+	queue := []position{from}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		if pos == to {
+			path := []position{pos}
+			for pos != from {
+				i := int(parent[index(pos)]) - 1
+				pos = position{x: i % m.width, y: i / m.width}
+				path = append(path, pos)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+
+		for _, dir := range []direction{north, south, east, west} {
+			np, err := dir.translate(pos, m)
+			if err != nil || !m.at(pos).openings[dir] || parent[index(np)] != 0 || blocked[index(np)] || steps[step{pos, np}] {
+				continue
+			}
+			parent[index(np)] = int32(index(pos)) + 1
+			queue = append(queue, np)
+		}
+	}
+	return nil
+}
+
+func samePath(a, b []position) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Find up to k shortest loop-free routes from start to finish, shortest
+// first, using Yen's algorithm: each new route leaves a previous one at
+// some cell (the spur), and takes the shortest way from there to the
+// finish that doesn't retrace the previous route's prefix or reuse a
+// step already taken from that prefix by a route we've found.
+//
+// No route can go into a dead end, so we fill them in first (see
+// fillDeadEnds) and leave them out of every search; in a mostly
+// perfect maze that's nearly all of it.
+func (m *maze) routes(k int) [][]position {
+	defer tr(ace("finding routes"))
+
+	rounds, _ := m.fillDeadEnds()
+	pruned := make([]bool, len(m.cells))
+	for _, round := range rounds {
+		for _, p := range round {
+			pruned[p.y*m.width+p.x] = true
+		}
+	}
+
+	first := m.shortestAvoiding(m.start, m.finish, pruned, nil)
+	if first == nil {
+		return nil
+	}
+	found := [][]position{first}
+	var candidates [][]position
+
+	known := func(path []position) bool {
+		for _, p := range append(found, candidates...) {
+			if samePath(p, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for len(found) < k {
+		prev := found[len(found)-1]
+
+		// The cells of the root path before each spur are blocked,
+		// which we keep track of as we go.
+		blocked := append([]bool{}, pruned...)
+		for i := 0; i < len(prev)-1; i++ {
+			if i > 0 {
+				blocked[prev[i-1].y*m.width+prev[i-1].x] = true
+			}
+			root := prev[:i+1]
+
+			steps := make(map[step]bool)
+			for _, p := range found {
+				if len(p) > i+1 && samePath(p[:i+1], root) {
+					steps[step{p[i], p[i+1]}] = true
+				}
+			}
+
+			spur := m.shortestAvoiding(prev[i], m.finish, blocked, steps)
+			if spur == nil {
+				continue
+			}
+			path := append(append([]position{}, root[:i]...), spur...)
+			if !known(path) {
+				candidates = append(candidates, path)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+		best := 0
+		for i, c := range candidates {
+			if len(c) < len(candidates[best]) {
+				best = i
+			}
+		}
+		found = append(found, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	return found
+}
+
+// Draw the given routes in their own colors, longest first so that
+// shorter routes stay on top.
+func (m *maze) drawRoutes(img draw.Image, routes [][]position) {
+	defer tr(ace("drawing routes"))
+
+	for i := len(routes) - 1; i >= 0; i-- {
+		route := routes[i]
+		for j := 1; j < len(route); j++ {
+			m.drawLine(img, route[j-1], route[j], routeColors[i])
+		}
+	}
+}
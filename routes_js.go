@@ -0,0 +1,15 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+)
+
+// Show how many routes we found next to the setting.
+func reportRoutes(count int) {
+	if e := element("solutionRoutes"); !e.IsNull() {
+		e.Get("nextElementSibling").Set("value", fmt.Sprintf("%d found", count))
+	}
+}
@@ -0,0 +1,195 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"syscall/js"
+)
+
+// Settings profiles save the settings form as JSON, so a configuration
+// can be shared: a teacher can hand out one profile and have the whole
+// class generate mazes of the same size, style, and print layout.
+// Profiles name settings by their input's ID; settings a profile
+// doesn't name are left alone, and ones this version doesn't know are
+// ignored, so profiles keep working as settings come and go.
+
+const profileVersion = 1 // Version written in profiles; newer ones are refused
+
+var (
+	badProfile   = errors.New("not a settings profile")
+	newerProfile = errors.New("settings profile is from a newer version")
+	badSetting   = errors.New("setting has the wrong type or an unknown choice")
+	noForm       = errors.New("page has no settings form")
+)
+
+// Settings left out of profiles: the seed picks out one maze rather than
+// configuring them all.
+var unprofiled = map[string]bool{
+	"randomSeed": true,
+}
+
+type profile struct {
+	Version  int                    `json:"version"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// The inputs and selects of the settings form that go in profiles.
+// Pages driving the API alone may have no form.
+func profiledElements() ([]js.Value, error) {
+	form := element("settingsForm")
+	if form.IsNull() || form.IsUndefined() {
+		return nil, noForm
+	}
+	elements := form.Get("elements")
+	var result []js.Value
+	for i := 0; i < elements.Get("length").Int(); i++ {
+		e := elements.Index(i)
+		id := e.Get("id").String()
+		tag := e.Get("tagName").String()
+		if id == "" || unprofiled[id] || (tag != "INPUT" && tag != "SELECT") {
+			continue
+		}
+		switch e.Get("type").String() {
+		case "file", "button", "submit", "reset":
+			continue
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// The current settings as a JSON profile.
+func exportSettings() ([]byte, error) {
+	elements, err := profiledElements()
+	if err != nil {
+		return nil, err
+	}
+	p := profile{Version: profileVersion, Settings: make(map[string]interface{})}
+	for _, e := range elements {
+		id := e.Get("id").String()
+		switch e.Get("type").String() {
+		case "checkbox":
+			p.Settings[id] = e.Get("checked").Bool()
+		case "number", "range":
+			if n := e.Get("valueAsNumber").Float(); !math.IsNaN(n) {
+				p.Settings[id] = n
+			}
+		default:
+			p.Settings[id] = e.Get("value").String()
+		}
+	}
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Whether the select has an option with the given value.
+func hasOption(sel js.Value, value string) bool {
+	options := sel.Get("options")
+	for i := 0; i < options.Get("length").Int(); i++ {
+		if options.Index(i).Get("value").String() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Set the settings form from a JSON profile. Every setting is checked
+// before any is changed, so a bad profile changes nothing. Each
+// changed input fires an input event, as if the user had changed it,
+// so that anything showing or following its value keeps up.
+func importSettings(data []byte) error {
+	var p profile
+	if err := json.Unmarshal(data, &p); err != nil || p.Version < 1 || p.Settings == nil {
+		return badProfile
+	}
+	if p.Version > profileVersion {
+		return newerProfile
+	}
+
+	elements, err := profiledElements()
+	if err != nil {
+		return err
+	}
+	var changes []func()
+	for _, e := range elements {
+		e := e
+		id := e.Get("id").String()
+		v, ok := p.Settings[id]
+		if !ok {
+			continue
+		}
+
+		switch e.Get("type").String() {
+		case "checkbox":
+			b, ok := v.(bool)
+			if !ok {
+				return fmt.Errorf("%s: %w", id, badSetting)
+			}
+			changes = append(changes, func() { e.Set("checked", b) })
+		case "number", "range":
+			n, ok := v.(float64)
+			if !ok {
+				return fmt.Errorf("%s: %w", id, badSetting)
+			}
+			changes = append(changes, func() { e.Set("value", n) })
+		default:
+			s, ok := v.(string)
+			if !ok || (e.Get("tagName").String() == "SELECT" && !hasOption(e, s)) {
+				return fmt.Errorf("%s: %w", id, badSetting)
+			}
+			changes = append(changes, func() { e.Set("value", s) })
+		}
+		changes = append(changes, func() {
+			e.Call("dispatchEvent", js.Global().Get("Event").New("input", map[string]interface{}{"bubbles": true}))
+		})
+	}
+
+	for _, change := range changes {
+		change()
+	}
+	return nil
+}
+
+// Offer the current settings as a profile download.
+func exportSettingsCallback() {
+	data, err := exportSettings()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
+	offerDownload.Invoke("maze-settings.json", "application/json", string(data))
+}
+
+// exportSettings() returns the current settings as a JSON profile.
+func exportSettingsJS(this js.Value, args []js.Value) interface{} {
+	data, err := exportSettings()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return js.Null()
+	}
+	return string(data)
+}
+
+// importSettings(json) sets the page's settings from a profile and,
+// if there's a maze, generates a new one with them. It returns null on
+// success, otherwise why not.
+func importSettingsJS(this js.Value, args []js.Value) interface{} {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return badProfile.Error()
+	}
+	if err := importSettings([]byte(args[0].String())); err != nil {
+		return err.Error()
+	}
+
+	// The input events may have started a live regeneration; we'll
+	// do it now instead.
+	regenerateLive.cancel()
+	if currentMaze != nil {
+		generateCallback()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+// In shifting-walls mode, every so many moves a few walls open and
+// others close while the player watches. Each shift opens a wall and
+// closes another passage on the loop that makes, so the maze stays
+// connected and the finish is always reachable from wherever the
+// player is. Only the cells that changed are redrawn.
+
+const (
+	maxShiftWalls = 20 // Most walls moved at once
+	shiftAttempts = 10 // Tries at finding a wall to open, per wall moved
+)
+
+// Put back the wall on the given side of a cell.
+func (m *maze) wallUp(p position, d direction) {
+	m.at(p).openings[d] = false
+	if np, err := d.translate(p, m); err == nil {
+		m.at(np).openings[d.opposite()] = false
+	}
+}
+
+// Move the given number of walls, returning the cells that changed.
+func (m *maze) shiftWalls(n int) []position {
+	defer tr(ace("shifting walls"))
+
+	if n > maxShiftWalls {
+		n = maxShiftWalls
+	}
+
+	var changed []position
+	for i := 0; i < n*shiftAttempts && len(changed) < n*4; i++ {
+		p := position{x: m.rng.Intn(m.width), y: m.rng.Intn(m.height)}
+		d := direction(m.rng.Intn(4))
+		if !m.solidWall(p, d) {
+			continue
+		}
+
+		// The way around the wall becomes a loop once it's open; close
+		// any one of its passages to break the loop again.
+		np, _ := d.translate(p, m)
+		loop := m.findPath(p, np)
+		if loop == nil {
+			continue
+		}
+		k := 1 + m.rng.Intn(len(loop)-1)
+		m.carve(p, d)
+		for _, dir := range []direction{north, south, east, west} {
+			if q, err := dir.translate(loop[k-1], m); err == nil && q == loop[k] {
+				m.wallUp(loop[k-1], dir)
+			}
+		}
+		changed = append(changed, p, np, loop[k-1], loop[k])
+	}
+	return changed
+}
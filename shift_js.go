@@ -0,0 +1,38 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Redraw just the given cells, and the player, and export the frame.
+// Clearing a cell clears the walls it shares with its neighbors, so
+// they're redrawn too. Overlays like the solution and junctions span
+// many cells, so with those shown the whole maze is redrawn instead.
+func (g *game) redrawCells(cells []position) {
+	if currentSolution != nil || showJunctions || showHeatmap || frameBuffer == nil {
+		g.redraw()
+		return
+	}
+	defer tr(ace("redrawing cells"))
+
+	img := frameBuffer
+	before, after := lineReach()
+	for _, p := range cells {
+		x, y := p.x*cellWidth+border, p.y*cellWidth+border
+		draw.Draw(img, image.Rect(x-before, y-before, x+cellWidth+after+1, y+cellWidth+after+1), background, image.Point{0, 0}, draw.Src)
+	}
+	for _, p := range cells {
+		g.m.drawCell(img, p.x, p.y, g.m.at(p))
+		for _, d := range []direction{north, south, east, west} {
+			if np, err := d.translate(p, g.m); err == nil {
+				g.m.drawCell(img, np.x, np.y, g.m.at(np))
+			}
+		}
+	}
+	g.drawPieces(img)
+	export(currentLabel)
+}
@@ -0,0 +1,52 @@
+package main
+
+// A perfect maze has exactly one solution. Puzzle setters sometimes
+// want a few: knocking down walls adds loops, and with them other
+// routes from start to finish. We knock down random walls one at a
+// time, keeping each only if it doesn't take us past the number of
+// routes asked for, until we have that many or run out of tries.
+// Like the rest of generation this uses the maze's RNG, so the same
+// seed and settings always give the same maze.
+
+const (
+	maxSolutions     = maxRoutes // Most solutions we'll aim for; we count them with routes
+	wallsPerSolution = 50        // Walls tried, per solution asked for, before giving up
+)
+
+// Whether the wall on the given side of a cell can be knocked down.
+func (m *maze) solidWall(p position, d direction) bool {
+	_, err := d.translate(p, m)
+	return err == nil && !m.at(p).openings[d]
+}
+
+// Knock down walls until the maze has the given number of distinct
+// solutions, if we can. Returns how many it has. Only walls beside the
+// routes we have are tried, since knocking down a wall off in a dead
+// end can't add a route.
+func (m *maze) addSolutions(target int) int {
+	defer tr(ace("adding solutions"))
+
+	if target > maxSolutions {
+		target = maxSolutions
+	}
+	routes := m.routes(target + 1)
+	for tries := 0; len(routes) < target && tries < target*wallsPerSolution; tries++ {
+		route := routes[m.rng.Intn(len(routes))]
+		p := route[m.rng.Intn(len(route))]
+		d := direction(m.rng.Intn(4))
+		if !m.solidWall(p, d) {
+			continue
+		}
+
+		m.carve(p, d)
+		more := m.routes(target + 1)
+		if len(more) > target {
+			// Too many; put the wall back.
+			m.wallUp(p, d)
+			continue
+		}
+		routes = more
+		m.loops = true
+	}
+	return len(routes)
+}
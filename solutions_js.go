@@ -0,0 +1,15 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+)
+
+// Show how many solutions we managed next to the setting.
+func reportSolutions(count int) {
+	if e := element("targetSolutions"); !e.IsNull() {
+		e.Get("nextElementSibling").Set("value", fmt.Sprintf("%d achieved", count))
+	}
+}
@@ -0,0 +1,155 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// A stitch chart is the thick wall grid (see dots) drawn for cross
+// stitch or knitting: one square per stitch, with a symbol in every
+// square to be stitched, heavier grid lines every ten stitches,
+// stitch counts along the edges, and a key. It's drawn on a canvas of
+// its own, so that the key can be set in the label font, and saved as
+// a PNG.
+
+const (
+	stitchSize   = 16 // Width/height of a stitch on the chart (in pixels)
+	stitchMargin = 32 // Margin around the chart, for the counts (in pixels)
+	stitchKey    = 56 // Height of the key below the chart (in pixels)
+	stitchMajor  = 10 // Stitches between heavy grid lines
+)
+
+// A thread in the chart's key.
+type floss struct {
+	name, symbol, color string
+}
+
+var (
+	wallFloss     = floss{"Walls", "×", "#202020"}
+	solutionFloss = floss{"Solution", "●", "#c00000"}
+)
+
+// Which floss each stitch of the chart is, if any, row by row.
+func (m *maze) stitches(path []position) [][]*floss {
+	grid := m.dots(2)
+	chart := make([][]*floss, len(grid))
+	for y, row := range grid {
+		chart[y] = make([]*floss, len(row))
+		for x, wall := range row {
+			if wall {
+				chart[y][x] = &wallFloss
+			}
+		}
+	}
+	for i, p := range path {
+		chart[p.y*2+1][p.x*2+1] = &solutionFloss
+		if i > 0 {
+			q := path[i-1]
+			chart[p.y+q.y+1][p.x+q.x+1] = &solutionFloss
+		}
+	}
+	return chart
+}
+
+// Draw the stitch chart on a new canvas.
+func (m *maze) stitchChart(path []position) js.Value {
+	defer tr(ace("drawing stitch chart"))
+
+	chart := m.stitches(path)
+	rows, columns := len(chart), len(chart[0])
+	width, height := columns*stitchSize, rows*stitchSize
+
+	canvas := js.Global().Get("document").Call("createElement", "canvas")
+	canvas.Set("width", width+stitchMargin*2)
+	canvas.Set("height", height+stitchMargin*2+stitchKey)
+	ctx := canvas.Call("getContext", "2d")
+	ctx.Set("fillStyle", "white")
+	ctx.Call("fillRect", 0, 0, canvas.Get("width"), canvas.Get("height"))
+	ctx.Set("font", labelFontSize+" "+labelFont)
+	ctx.Set("textAlign", "center")
+	ctx.Set("textBaseline", "middle")
+
+	counts := map[*floss]int{}
+	for y, row := range chart {
+		for x, f := range row {
+			if f == nil {
+				continue
+			}
+			counts[f]++
+			ctx.Set("fillStyle", f.color)
+			ctx.Call("fillText", f.symbol, stitchMargin+x*stitchSize+stitchSize/2, stitchMargin+y*stitchSize+stitchSize/2)
+		}
+	}
+
+	// Grid lines, heavier every stitchMajor stitches, with counts.
+	line := func(x0, y0, x1, y1 float64, major bool) {
+		ctx.Call("beginPath")
+		ctx.Set("lineWidth", 1)
+		ctx.Set("strokeStyle", "#b0b0b0")
+		if major {
+			ctx.Set("lineWidth", 2)
+			ctx.Set("strokeStyle", "black")
+		}
+		ctx.Call("moveTo", x0, y0)
+		ctx.Call("lineTo", x1, y1)
+		ctx.Call("stroke")
+	}
+	ctx.Set("fillStyle", "black")
+	left, top := float64(stitchMargin), float64(stitchMargin)
+	for x := 0; x <= columns; x++ {
+		major := x%stitchMajor == 0 || x == columns
+		line(left+float64(x*stitchSize)+0.5, top, left+float64(x*stitchSize)+0.5, top+float64(height), major)
+		if x%stitchMajor == 0 && x > 0 {
+			ctx.Call("fillText", fmt.Sprint(x), left+float64(x*stitchSize), top/2)
+		}
+	}
+	for y := 0; y <= rows; y++ {
+		major := y%stitchMajor == 0 || y == rows
+		line(left, top+float64(y*stitchSize)+0.5, left+float64(width), top+float64(y*stitchSize)+0.5, major)
+		if y%stitchMajor == 0 && y > 0 {
+			ctx.Call("fillText", fmt.Sprint(y), left/2, top+float64(y*stitchSize))
+		}
+	}
+
+	// The key.
+	ctx.Set("textAlign", "left")
+	y := top + float64(height) + stitchMargin
+	ctx.Call("fillText", fmt.Sprintf("%d × %d stitches", columns, rows), left, y)
+	x := left
+	for _, f := range []*floss{&wallFloss, &solutionFloss} {
+		if counts[f] == 0 {
+			continue
+		}
+		y := y + stitchSize*1.5
+		ctx.Call("strokeRect", x+0.5, y-stitchSize/2+0.5, stitchSize, stitchSize)
+		ctx.Set("textAlign", "center")
+		ctx.Set("fillStyle", f.color)
+		ctx.Call("fillText", f.symbol, x+stitchSize/2, y)
+		ctx.Set("textAlign", "left")
+		ctx.Set("fillStyle", "black")
+		text := fmt.Sprintf("%s (%d stitches)", f.name, counts[f])
+		ctx.Call("fillText", text, x+stitchSize*1.5, y)
+		x += stitchSize*2.5 + ctx.Call("measureText", text).Get("width").Float()
+	}
+	return canvas
+}
+
+// Export the current maze as a stitch chart, with its solution if
+// it's being shown.
+func exportStitchCallback() {
+	if !exportable() {
+		return
+	}
+	canvas := currentMaze.stitchChart(currentSolution)
+
+	var saved js.Func
+	saved = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer saved.Release()
+		offerDownload.Invoke("maze-chart.png", "image/png", args[0])
+		return nil
+	})
+	canvas.Call("toBlob", saved, "image/png")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tactile graphics are read by touch, so their geometry is set by
+// fingertips rather than pixels. These follow the usual guidance for
+// swell paper and graphics embossers: dots on a 2.5mm pitch, 1.5mm
+// across, with parallel lines at least 6mm apart.
+const (
+	tactileDotPitch    = 2.5 // Distance between dot centers (in mm)
+	tactileDotDiameter = 1.5 // Diameter of a single dot (in mm)
+	tactileCellDots    = 4   // Dots per cell edge, making cells 10mm across
+	tactileMargin      = 10  // Margin around the maze (in mm)
+)
+
+// Map the walls of the maze onto a grid of dots. Each cell is pitch
+// dots across, so the grid is (height*pitch+1) rows of (width*pitch+1)
+// dots, and grid[y][x] is true where a wall passes through that dot.
+//
+// With a pitch of 2 this is the familiar "thick wall" grid, where
+// walls, passages, and cells all take up one square each.
+func (m *maze) dots(pitch int) [][]bool {
+	grid := make([][]bool, m.height*pitch+1)
+	for y := range grid {
+		grid[y] = make([]bool, m.width*pitch+1)
+	}
+
+	for _, s := range m.walls() {
+		for y := s.y0 * pitch; y <= s.y1*pitch; y++ {
+			for x := s.x0 * pitch; x <= s.x1*pitch; x++ {
+				grid[y][x] = true
+			}
+		}
+	}
+
+	return grid
+}
+
+// Render the maze as a Braille Ready File for text embossers.
+//
+// Each square of the thick wall grid becomes one braille cell: walls
+// are full cells (= in ASCII braille, dots 1-2-3-4-5-6), passages are
+// blank, and the start and finish are marked with the letters s and f.
+// Lines are not wrapped, so mazes wider than the embosser's line
+// length (typically 40 cells, or a maze 19 cells wide) will be cut off.
+func (m *maze) brf() string {
+	defer tr(ace("rendering brf"))
+
+	grid := m.dots(2)
+
+	var b strings.Builder
+	for y, row := range grid {
+		for x, wall := range row {
+			switch {
+			case x == m.start.x*2+1 && y == m.start.y*2+1:
+				b.WriteByte('s')
+			case x == m.finish.x*2+1 && y == m.finish.y*2+1:
+				b.WriteByte('f')
+			case wall:
+				b.WriteByte('=')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("\r\n")
+	}
+
+	return b.String()
+}
+
+// Render the maze as an SVG of raised dots, sized in millimeters, for
+// swell paper and graphics embossers. The start and finish are marked
+// with a larger dot.
+func (m *maze) tactileSVG() string {
+	defer tr(ace("rendering tactile svg"))
+
+	grid := m.dots(tactileCellDots)
+	width := float64(len(grid[0])-1)*tactileDotPitch + tactileMargin*2
+	height := float64(len(grid)-1)*tactileDotPitch + tactileMargin*2
+
+	dot := func(x, y, r float64) string {
+		return fmt.Sprintf(`<circle cx="%g" cy="%g" r="%g"/>`+"\n", x*tactileDotPitch+tactileMargin, y*tactileDotPitch+tactileMargin, r)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g">`+"\n", width, height, width, height)
+	b.WriteString(`<g fill="black">` + "\n")
+	for y, row := range grid {
+		for x, wall := range row {
+			if wall {
+				b.WriteString(dot(float64(x), float64(y), tactileDotDiameter/2))
+			}
+		}
+	}
+	for _, p := range []position{m.start, m.finish} {
+		center := float64(tactileCellDots) / 2
+		b.WriteString(dot(float64(p.x*tactileCellDots)+center, float64(p.y*tactileCellDots)+center, tactileDotDiameter*1.5))
+	}
+	b.WriteString("</g>\n</svg>\n")
+
+	return b.String()
+}
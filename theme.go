@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+)
+
+// The theme is how mazes are drawn: how big cells are, the border
+// around them, how thick lines are, and the colors of the walls, the
+// background, and the solution. It's set before each maze is drawn and
+// holds until the next, so everything that works out where things are
+// in the image, from the renderers to the pointer, agrees on it. The
+// default theme is how mazes have always looked.
+
+var badColor = errors.New("colors are #rrggbb")
+
+type theme struct {
+	cellSize, border, lineWidth int // In pixels
+	wall, background, solution  color.RGBA
+}
+
+const (
+	minCellSize = 4  // (in pixels)
+	maxCellSize = 48 // (in pixels)
+	maxBorder   = 200
+	minBorder   = 8 // Room for the label (in pixels)
+
+	defaultCellSize = 12 // (in pixels)
+	defaultBorder   = 40
+
+	// Most pixels on a side of a maze's image drawn whole: what
+	// maxDrawnDimension cells take at the default theme. Themes with
+	// bigger cells draw fewer cells whole; see maxDrawnCells.
+	maxDrawnSide = maxDrawnDimension*defaultCellSize + defaultBorder*2
+)
+
+var defaultTheme = theme{
+	cellSize:   defaultCellSize,
+	border:     defaultBorder,
+	lineWidth:  1,
+	wall:       color.RGBA{0, 0, 0, 255},
+	background: color.RGBA{255, 255, 255, 255},
+	solution:   color.RGBA{255, 0, 0, 255},
+}
+
+// The current theme, and the parts of it the renderers use.
+var (
+	currentTheme  = defaultTheme
+	border        = defaultTheme.border            // Border (in pixels) around the maze
+	cellWidth     = defaultTheme.cellSize          // Width/height (in pixels) of a single cell
+	halfCellWidth = cellWidth / 2                  // Used to find the midpoint of a cell
+	minImageWidth = cellWidth*4 + border*2         // Minimum width of generated image (in pixels)
+	lineWidth     = defaultTheme.lineWidth         // Thickness of lines (in pixels)
+	wallColor     = image.NewUniform(color.RGBA{}) // These three are changed in place, so
+	background    = image.NewUniform(color.RGBA{}) // palettes holding them follow along
+	solutionColor = image.NewUniform(color.RGBA{})
+)
+
+func init() {
+	useTheme(defaultTheme)
+}
+
+// Make the given theme current, keeping its sizes within limits.
+func useTheme(t theme) {
+	t.cellSize = clampInt(t.cellSize, minCellSize, maxCellSize)
+	t.border = clampInt(t.border, minBorder, maxBorder)
+	t.lineWidth = clampInt(t.lineWidth, 1, t.cellSize/2)
+
+	currentTheme = t
+	border, cellWidth, halfCellWidth = t.border, t.cellSize, t.cellSize/2
+	minImageWidth = cellWidth*4 + border*2
+	lineWidth = t.lineWidth
+	wallColor.C, background.C, solutionColor.C = t.wall, t.background, t.solution
+}
+
+func clampInt(n, min, max int) int {
+	switch {
+	case n < min:
+		return min
+	case n > max:
+		return max
+	}
+	return n
+}
+
+// How far a line of the current width reaches either side of the
+// pixel it's drawn along: up and to the left, then down and to the
+// right.
+func lineReach() (before, after int) {
+	return (lineWidth - 1) / 2, lineWidth / 2
+}
+
+// Parse a color as HTML color inputs give it, #rrggbb.
+func parseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, badColor
+	}
+	n, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, badColor
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, nil
+}
+
+// Format a color as CSS and HTML color inputs take it.
+func cssColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
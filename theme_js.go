@@ -0,0 +1,31 @@
+//go:build js
+// +build js
+
+package main
+
+import "image/color"
+
+// Read the theme from the settings. Sizes that aren't numbers, and
+// colors that aren't #rrggbb, are left as the default theme's.
+func themeFrom(s settingSource) theme {
+	t := defaultTheme
+	for id, n := range map[string]*int{
+		"cellSize":      &t.cellSize,
+		"borderSize":    &t.border,
+		"wallThickness": &t.lineWidth,
+	} {
+		if v := s.number(id); v >= 0 {
+			*n = int(v)
+		}
+	}
+	for id, c := range map[string]*color.RGBA{
+		"wallColor":       &t.wall,
+		"backgroundColor": &t.background,
+		"solutionColor":   &t.solution,
+	} {
+		if parsed, err := parseColor(s.value(id)); err == nil {
+			*c = parsed
+		}
+	}
+	return t
+}
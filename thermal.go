@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Thermal receipt printers print at 203dpi, or 8 dots per mm, across
+// a fixed number of dots for each paper width.
+var thermalWidths = map[int]int{
+	58: 384,
+	80: 576,
+}
+
+const (
+	thermalMargin    = 16  // Margin around the maze (in dots)
+	thermalWall      = 2   // Thickness of walls (in dots); single dots print too faintly
+	thermalMinCell   = 4   // Smallest legible cell (in dots)
+	thermalBandLines = 256 // Many printers can't take a raster image taller than this
+)
+
+var tooWide = errors.New("maze too wide for paper")
+
+// A 1-bit packed bitmap, one bit per dot, most significant bit first,
+// with each row padded out to a whole byte. Set bits are black; this
+// is the layout both PBM and ESC/POS raster images use.
+type bitmap struct {
+	width, height, stride int
+	bits                  []byte
+}
+
+func newBitmap(width, height int) *bitmap {
+	stride := (width + 7) / 8
+	return &bitmap{
+		width:  width,
+		height: height,
+		stride: stride,
+		bits:   make([]byte, stride*height),
+	}
+}
+
+// Set all dots in the rectangle from (x0, y0) up to but not including
+// (x1, y1).
+func (b *bitmap) fill(x0, y0, x1, y1 int) {
+	for y := y0; y < y1 && y < b.height; y++ {
+		for x := x0; x < x1 && x < b.width; x++ {
+			b.bits[y*b.stride+x/8] |= 0x80 >> (x % 8)
+		}
+	}
+}
+
+// Render the maze as a 1-bit bitmap fitting the given number of dots
+// across. The maze is scaled to fill the width, and the bitmap is as
+// tall as needed.
+func (m *maze) bitmap(dots int, path []position) (*bitmap, error) {
+	defer tr(ace("rendering bitmap"))
+
+	cell := (dots - thermalMargin*2 - thermalWall) / m.width
+	if cell < thermalMinCell {
+		return nil, tooWide
+	}
+
+	img := newBitmap(dots, m.height*cell+thermalWall+thermalMargin*2)
+	offset := (dots - m.width*cell - thermalWall) / 2
+	for _, s := range m.walls() {
+		img.fill(
+			s.x0*cell+offset, s.y0*cell+thermalMargin,
+			s.x1*cell+offset+thermalWall, s.y1*cell+thermalMargin+thermalWall,
+		)
+	}
+
+	cx := offset + thermalWall/2 + cell/2
+	cy := thermalMargin + thermalWall/2 + cell/2
+	for i := 1; i < len(path); i++ {
+		first, last := path[i-1], path[i]
+		if first.x > last.x || first.y > last.y {
+			first, last = last, first
+		}
+		img.fill(first.x*cell+cx, first.y*cell+cy, last.x*cell+cx+1, last.y*cell+cy+1)
+	}
+
+	return img, nil
+}
+
+// Encode the bitmap as a binary PBM image.
+func (b *bitmap) pbm() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "P4\n%d %d\n", b.width, b.height)
+	buf.Write(b.bits)
+	return buf.Bytes()
+}
+
+// Encode the bitmap as ESC/POS commands that print it as a raster
+// image, in bands short enough for any printer, then feed the paper
+// far enough to tear it off.
+func (b *bitmap) escpos() []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x1b, '@'}) // ESC @: initialize printer
+
+	for y := 0; y < b.height; y += thermalBandLines {
+		lines := b.height - y
+		if lines > thermalBandLines {
+			lines = thermalBandLines
+		}
+
+		// GS v 0: print raster image, normal density.
+		buf.Write([]byte{0x1d, 'v', '0', 0,
+			byte(b.stride), byte(b.stride >> 8),
+			byte(lines), byte(lines >> 8),
+		})
+		buf.Write(b.bits[y*b.stride : (y+lines)*b.stride])
+	}
+
+	buf.Write([]byte{0x1b, 'd', 4}) // ESC d: feed four lines
+	return buf.Bytes()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image"
+)
+
+// Draw the maze scaled down to fit in a square of the given size (in
+// pixels), for previews. Each thumbnail pixel is the average of the
+// pixels it covers, so walls too thin to survive the scaling come out
+// gray rather than vanishing. Mazes that already fit aren't scaled,
+// and mazes too big to draw whole are drawn straight at the
+// thumbnail's size, through a viewport onto all of them.
+func (m *maze) thumbnail(size int) *image.RGBA {
+	defer tr(ace("drawing thumbnail"))
+
+	width, height := m.imageSize()
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	tw, th := width*size/longest, height*size/longest
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+	if m.viewOnly() {
+		thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+		m.drawView(thumb, m.clampView(viewport{width: tw, height: th}), nil)
+		return thumb
+	}
+
+	full := image.NewRGBA(image.Rect(0, 0, width, height))
+	m.drawOnto(full)
+	if longest <= size {
+		return full
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for ty := 0; ty < th; ty++ {
+		y0, y1 := ty*height/th, (ty+1)*height/th
+		for tx := 0; tx < tw; tx++ {
+			x0, x1 := tx*width/tw, (tx+1)*width/tw
+
+			var sum [4]int
+			for y := y0; y < y1; y++ {
+				row := full.Pix[full.PixOffset(x0, y):full.PixOffset(x1, y)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			pix := thumb.Pix[thumb.PixOffset(tx, ty):]
+			for i := range sum {
+				pix[i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return thumb
+}
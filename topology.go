@@ -0,0 +1,445 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+)
+
+// Mazes needn't be carved out of squares. A shaped maze is a graph of
+// cells, each with a list of the cells beside it, one per side, and
+// knocking down the wall on a side opens a passage to that neighbor.
+// The grid's topology says which cells are neighbors and how to draw
+// them: hexagons, rings of cells around a center (a theta maze), or
+// floors of squares with stairs between them (see levels.go).
+// Generating and solving only need the graph. Shaped mazes are drawn
+// and solved, but everything built on the square grid (playing, the
+// exporters, and so on) only works on square mazes.
+
+// Topologies, as the topology setting names them.
+const (
+	squareTopology = "square"
+	hexTopology    = "hex"
+	thetaTopology  = "theta"
+	levelsTopology = "levels"
+)
+
+const maxRings = maxDrawnDimension / 2 // Most rings in a theta maze
+
+// The shape of a grid of cells.
+type topology interface {
+	// The cells beside each cell, one per side; -1 for a side on the
+	// edge of the grid.
+	neighbors() [][]int
+
+	// Where the entrance and exit are: the cells, and the sides of
+	// them that open out of the grid, -1 if none does.
+	ends(rng *rand.Rand) (start, startSide, finish, finishSide int)
+
+	imageSize() (width, height int)
+	center(i int) (x, y float64)
+	drawWall(img draw.Image, i, side int)
+}
+
+// A maze on a grid of any shape.
+type shapedMaze struct {
+	shape         topology
+	adj           [][]int  // adj[i][side] is the cell on that side of cell i
+	open          [][]bool // open[i][side] is whether that wall is down
+	start, finish int
+	rng           *rand.Rand
+}
+
+func newShapedMaze(shape topology, rng *rand.Rand) *shapedMaze {
+	m := &shapedMaze{shape: shape, adj: shape.neighbors(), rng: rng}
+	m.open = make([][]bool, len(m.adj))
+	for i, sides := range m.adj {
+		m.open[i] = make([]bool, len(sides))
+	}
+	return m
+}
+
+// Knock down the wall on the given side of a cell, from both sides.
+func (m *shapedMaze) carve(i, side int) {
+	m.open[i][side] = true
+	j := m.adj[i][side]
+	if j < 0 {
+		return
+	}
+	for k, n := range m.adj[j] {
+		if n == i {
+			m.open[j][k] = true
+		}
+	}
+}
+
+// Generate the maze with the recursive backtracker, as generate does
+// for square mazes. Stairs are only taken where there's nowhere else
+// to go, so they're few, and each floor is mostly walked on its own.
+func (m *shapedMaze) generate() {
+	defer tr(ace("generating shaped maze"))
+
+	var startSide, finishSide int
+	m.start, startSide, m.finish, finishSide = m.shape.ends(m.rng)
+
+	s, _ := m.shape.(stairs)
+	visited := make([]bool, len(m.adj))
+	visited[m.start] = true
+	stack := []int{m.start}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		var sides, climbs []int
+		for side, j := range m.adj[i] {
+			switch {
+			case j < 0 || visited[j]:
+			case s != nil && s.isStair(side):
+				climbs = append(climbs, side)
+			default:
+				sides = append(sides, side)
+			}
+		}
+		if len(sides) == 0 {
+			sides = climbs
+		}
+		if len(sides) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		side := sides[m.rng.Intn(len(sides))]
+		m.carve(i, side)
+		visited[m.adj[i][side]] = true
+		stack = append(stack, m.adj[i][side])
+	}
+
+	if startSide >= 0 {
+		m.carve(m.start, startSide)
+	}
+	if finishSide >= 0 {
+		m.carve(m.finish, finishSide)
+	}
+}
+
+// The cells from the start to the finish, via breadth-first search.
+func (m *shapedMaze) solution() []int {
+	defer tr(ace("solving shaped maze"))
+
+	parent := make([]int, len(m.adj))
+	for i := range parent {
+		parent[i] = -1
+	}
+	parent[m.start] = m.start
+	queue := []int{m.start}
+	for len(queue) > 0 && parent[m.finish] < 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for side, j := range m.adj[i] {
+			if j >= 0 && m.open[i][side] && parent[j] < 0 {
+				parent[j] = i
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	path := []int{m.finish}
+	for i := m.finish; i != m.start; i = parent[i] {
+		path = append(path, parent[i])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Draw the maze onto a new image.
+func (m *shapedMaze) draw() *image.RGBA {
+	defer tr(ace("drawing shaped maze"))
+
+	width, height := m.shape.imageSize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, 0, height, 0, width, background)
+	s, _ := m.shape.(stairs)
+	for i, sides := range m.open {
+		for side, open := range sides {
+			switch {
+			case s != nil && s.isStair(side):
+				if open {
+					s.drawStair(img, i, side, wallColor.C)
+				}
+			case !open:
+				m.shape.drawWall(img, i, side)
+			}
+		}
+	}
+	return img
+}
+
+// Draw the given path through the maze's cells. Where it takes a stair
+// the stair's marks are drawn over instead, at both ends.
+func (m *shapedMaze) drawPath(img draw.Image, path []int) {
+	s, _ := m.shape.(stairs)
+	for k := 1; k < len(path); k++ {
+		if s != nil {
+			if side := m.sideTo(path[k-1], path[k]); s.isStair(side) {
+				s.drawStair(img, path[k-1], side, solutionColor.C)
+				s.drawStair(img, path[k], m.sideTo(path[k], path[k-1]), solutionColor.C)
+				continue
+			}
+		}
+		x0, y0 := m.shape.center(path[k-1])
+		x1, y1 := m.shape.center(path[k])
+		line(img, x0, y0, x1, y1, solutionColor.C)
+	}
+}
+
+// The side of one cell leading to another beside it, or -1.
+func (m *shapedMaze) sideTo(i, j int) int {
+	for side, n := range m.adj[i] {
+		if n == j {
+			return side
+		}
+	}
+	return -1
+}
+
+// Draw a line between two points, as thick as the theme says.
+func line(img draw.Image, x0, y0, x1, y1 float64, col color.Color) {
+	before, after := lineReach()
+	stamp := image.NewUniform(col)
+	dot := func(x, y float64) {
+		px, py := int(math.Round(x)), int(math.Round(y))
+		draw.Draw(img, image.Rect(px-before, py-before, px+after+1, py+after+1), stamp, image.Point{}, draw.Over)
+	}
+
+	steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	if steps == 0 {
+		dot(x0, y0)
+		return
+	}
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / float64(steps)
+		dot(x0+t*(x1-x0), y0+t*(y1-y0))
+	}
+}
+
+// Draw an arc of a circle, from one angle (in radians, clockwise from
+// the positive x axis, as the y axis points down) to another.
+func arc(img draw.Image, cx, cy, radius, from, to float64, col color.Color) {
+	segments := int(math.Ceil(radius*(to-from)/2)) + 1
+	px, py := cx+radius*math.Cos(from), cy+radius*math.Sin(from)
+	for s := 1; s <= segments; s++ {
+		a := from + (to-from)*float64(s)/float64(segments)
+		x, y := cx+radius*math.Cos(a), cy+radius*math.Sin(a)
+		line(img, px, py, x, y, col)
+		px, py = x, y
+	}
+}
+
+// A grid of pointy-topped hexagons in offset rows, odd rows shifted
+// half a cell right. Sides run clockwise from the east: east, southeast,
+// southwest, west, northwest, northeast.
+type hexGrid struct {
+	rows, columns int
+}
+
+// The distance from a hexagon's center to its corners; hexagons are a
+// cell wide from side to side.
+func hexRadius() float64 {
+	return float64(cellWidth) / 1.7320508075688772 // The square root of 3
+}
+
+func (g hexGrid) neighbors() [][]int {
+	// Offsets to the neighbor on each side, for even and odd rows.
+	offsets := [2][6][2]int{
+		{{1, 0}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}},
+		{{1, 0}, {1, 1}, {0, 1}, {-1, 0}, {0, -1}, {1, -1}},
+	}
+
+	adj := make([][]int, g.rows*g.columns)
+	for i := range adj {
+		row, column := i/g.columns, i%g.columns
+		adj[i] = make([]int, 6)
+		for side, o := range offsets[row%2] {
+			r, c := row+o[1], column+o[0]
+			adj[i][side] = -1
+			if r >= 0 && r < g.rows && c >= 0 && c < g.columns {
+				adj[i][side] = r*g.columns + c
+			}
+		}
+	}
+	return adj
+}
+
+// The entrance is on the northeast side of a cell in the top row, and
+// the exit on the southeast side of one in the bottom row.
+func (g hexGrid) ends(rng *rand.Rand) (start, startSide, finish, finishSide int) {
+	return rng.Intn(g.columns), 5, (g.rows-1)*g.columns + rng.Intn(g.columns), 1
+}
+
+func (g hexGrid) imageSize() (width, height int) {
+	return int(math.Ceil(float64(cellWidth)*(float64(g.columns)+0.5))) + border*2 + 1,
+		int(math.Ceil(hexRadius()*(1.5*float64(g.rows)+0.5))) + border*2 + 1
+}
+
+func (g hexGrid) center(i int) (x, y float64) {
+	row, column := i/g.columns, i%g.columns
+	x = float64(border) + float64(cellWidth)*(float64(column)+0.5*float64(row%2)+0.5)
+	y = float64(border) + hexRadius()*(1.5*float64(row)+1)
+	return x, y
+}
+
+// The side runs between the corners either side of the direction it
+// faces.
+func (g hexGrid) drawWall(img draw.Image, i, side int) {
+	x, y := g.center(i)
+	a0 := float64(60*side-30) * math.Pi / 180
+	a1 := float64(60*side+30) * math.Pi / 180
+	r := hexRadius()
+	line(img, x+r*math.Cos(a0), y+r*math.Sin(a0), x+r*math.Cos(a1), y+r*math.Sin(a1), wallColor.C)
+}
+
+// A theta maze: rings of cells around a single cell in the middle. The
+// further out a ring, the more cells it's split into, so that cells
+// stay roughly square; each ring has a whole number of cells for each
+// one in the ring inside it.
+type thetaGrid struct {
+	rings  int
+	counts []int         // Cells in each ring
+	first  []int         // Index of each ring's first cell
+	sides  [][]thetaSide // What each cell's sides are
+}
+
+// Kinds of side of a theta cell.
+type thetaSide int
+
+const (
+	inwardSide    thetaSide = iota // Toward the middle
+	clockwiseSide                  // To the next cell round the ring, clockwise
+	counterSide                    // To the next cell round the ring, counterclockwise
+	outwardSide                    // To one of the cells in the ring outside
+	rimSide                        // Out of the maze, from the outermost ring
+)
+
+func newThetaGrid(rings int) *thetaGrid {
+	if rings > maxRings {
+		rings = maxRings
+	}
+	g := &thetaGrid{rings: rings, counts: []int{1}, first: []int{0}}
+	for r := 1; r < rings; r++ {
+		previous := g.counts[r-1]
+		width := 2 * math.Pi * float64(r) / float64(previous)
+		ratio := int(math.Round(width))
+		if ratio < 1 {
+			ratio = 1
+		}
+		g.first = append(g.first, g.first[r-1]+previous)
+		g.counts = append(g.counts, previous*ratio)
+	}
+	return g
+}
+
+// The ring and place in it of a cell.
+func (g *thetaGrid) locate(i int) (ring, k int) {
+	ring = g.rings - 1
+	for g.first[ring] > i {
+		ring--
+	}
+	return ring, i - g.first[ring]
+}
+
+func (g *thetaGrid) neighbors() [][]int {
+	cells := g.first[g.rings-1] + g.counts[g.rings-1]
+	adj := make([][]int, cells)
+	g.sides = make([][]thetaSide, cells)
+	for i := range adj {
+		r, k := g.locate(i)
+		n := g.counts[r]
+		add := func(kind thetaSide, j int) {
+			adj[i] = append(adj[i], j)
+			g.sides[i] = append(g.sides[i], kind)
+		}
+
+		if r > 0 {
+			add(inwardSide, g.first[r-1]+k/(n/g.counts[r-1]))
+			add(clockwiseSide, g.first[r]+(k+1)%n)
+			add(counterSide, g.first[r]+(k+n-1)%n)
+		}
+		if r < g.rings-1 {
+			ratio := g.counts[r+1] / n
+			for c := 0; c < ratio; c++ {
+				add(outwardSide, g.first[r+1]+k*ratio+c)
+			}
+		} else {
+			add(rimSide, -1)
+		}
+	}
+	return adj
+}
+
+// The entrance is the cell in the middle, and the exit out through the
+// rim of a cell in the outermost ring.
+func (g *thetaGrid) ends(rng *rand.Rand) (start, startSide, finish, finishSide int) {
+	last := g.rings - 1
+	finish = g.first[last] + rng.Intn(g.counts[last])
+	return 0, -1, finish, len(g.sides[finish]) - 1
+}
+
+func (g *thetaGrid) imageSize() (width, height int) {
+	size := 2*g.rings*cellWidth + border*2 + 1
+	return size, size
+}
+
+func (g *thetaGrid) middle() float64 {
+	return float64(border + g.rings*cellWidth)
+}
+
+// The angles either side of a cell.
+func (g *thetaGrid) span(r, k int) (from, to float64) {
+	n := float64(g.counts[r])
+	return 2 * math.Pi * float64(k) / n, 2 * math.Pi * float64(k+1) / n
+}
+
+func (g *thetaGrid) center(i int) (x, y float64) {
+	r, k := g.locate(i)
+	if r == 0 {
+		return g.middle(), g.middle()
+	}
+	from, to := g.span(r, k)
+	a, radius := (from+to)/2, (float64(r)+0.5)*float64(cellWidth)
+	return g.middle() + radius*math.Cos(a), g.middle() + radius*math.Sin(a)
+}
+
+// Walls toward the outside are drawn as the inward walls of the cells
+// beyond them, except the rim.
+func (g *thetaGrid) drawWall(img draw.Image, i, side int) {
+	r, k := g.locate(i)
+	from, to := g.span(r, k)
+	c := g.middle()
+	inner, outer := float64(r*cellWidth), float64((r+1)*cellWidth)
+	switch g.sides[i][side] {
+	case inwardSide:
+		arc(img, c, c, inner, from, to, wallColor.C)
+	case clockwiseSide:
+		line(img, c+inner*math.Cos(to), c+inner*math.Sin(to), c+outer*math.Cos(to), c+outer*math.Sin(to), wallColor.C)
+	case counterSide:
+		line(img, c+inner*math.Cos(from), c+inner*math.Sin(from), c+outer*math.Cos(from), c+outer*math.Sin(from), wallColor.C)
+	case rimSide:
+		arc(img, c, c, outer, from, to, wallColor.C)
+	}
+}
+
+// The grid of the given topology and size, or nil for square grids.
+// Only stacked grids have more than one level.
+func shapedGrid(kind string, height, width, levels int) topology {
+	switch kind {
+	case levelsTopology:
+		return levelGrid{levels: clampInt(levels, 2, maxLevels), rows: height, columns: width}
+	case hexTopology:
+		return hexGrid{rows: height, columns: width}
+	case thetaTopology:
+		return newThetaGrid(height)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"math/rand"
+)
+
+// Generate and draw a shaped maze of the given topology with the given
+// settings. Like the comparison, it isn't a maze we can export or play.
+func renderShaped(kind string, seed int64, args arguments) {
+	defer tr(ace("rendering shaped maze"))
+
+	stopAnimation()
+	stopGame()
+	showGLCanvas(false)
+	resetCanvasStyle()
+	currentMaze, currentSolution, currentWorld = nil, nil, nil
+
+	m := newShapedMaze(shapedGrid(kind, int(args.height), int(args.width), args.levels), rand.New(rand.NewSource(seed)))
+	m.generate()
+	img := m.draw()
+	if args.solution {
+		m.drawPath(img, m.solution())
+	}
+	frameBuffer, frameBufferMaze = img, nil
+	dither(img, args.ditherMethod)
+	export("")
+}
@@ -0,0 +1,21 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Simple timing functions, put
+// tr(ace(message))
+// at the top of a timed function. Outside the browser timings go to
+// stdout; see trace_js.go for the browser.
+func ace(message string) (string, time.Time) {
+	return message, time.Now()
+}
+
+func tr(message string, start time.Time) {
+	fmt.Printf("%v: %v\n", message, time.Since(start))
+}
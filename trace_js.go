@@ -0,0 +1,55 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+)
+
+// In the browser, timings are User Timing entries rather than lines on
+// the console: a mark when a timed function starts and a measure of it
+// when it returns, so they show up in the Performance panel and can be
+// collected by RUM tools with a PerformanceObserver. Entry names are
+// the message with timingPrefix in front.
+//
+// The measure is given its start and end explicitly rather than by the
+// start mark's name, so that a timed function running inside another
+// with the same message can't find the wrong mark, or none. The mark
+// is cleared once measured, leaving one entry per call.
+
+const timingPrefix = "mazegen: "
+
+var performance js.Value = js.Global().Get("performance")
+
+func hasPerformance() bool {
+	return performance.Type() == js.TypeObject && performance.Get("measure").Type() == js.TypeFunction
+}
+
+// Simple timing functions, put
+// tr(ace(message))
+// at the top of a timed function.
+func ace(message string) (string, time.Time) {
+	if hasPerformance() {
+		performance.Call("mark", timingPrefix+message+" start")
+	}
+	return message, time.Now()
+}
+
+func tr(message string, start time.Time) {
+	elapsed := time.Since(start)
+	if !hasPerformance() {
+		fmt.Printf("%v: %v\n", message, elapsed)
+		return
+	}
+
+	name := timingPrefix + message
+	end := performance.Call("now").Float()
+	performance.Call("measure", name, map[string]interface{}{
+		"start": end - float64(elapsed)/float64(time.Millisecond),
+		"end":   end,
+	})
+	performance.Call("clearMarks", name+" start")
+}
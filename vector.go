@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// A wall segment, in cell coordinates. Segments are always either
+// horizontal or vertical and run from (x0, y0) to (x1, y1). Those
+// returned by walls have x0 <= x1 and y0 <= y1.
+type segment struct {
+	x0, y0, x1, y1 int
+}
+
+const cssPixelsPerMM = 96 / 25.4 // A CSS pixel is 1/96 inch
+
+// Collect the walls of the maze as segments for vector output.
+//
+// Adjacent collinear walls are merged into a single segment, so a
+// wall that runs unbroken along the edge of the maze is one line
+// rather than one line per cell. This makes vector output much
+// smaller, and plotters and lasers much faster, than emitting every
+// cell edge on its own.
+func (m *maze) walls() []segment {
+	defer tr(ace("collecting walls"))
+
+	var segments []segment
+
+	// Horizontal walls, one grid line at a time. Grid line y is the
+	// north edge of row y, except for the last which is the south
+	// edge of the bottom row.
+	for y := 0; y <= m.height; y++ {
+		run := -1
+		for x := 0; x <= m.width; x++ {
+			closed := false
+			if x < m.width {
+				if y < m.height {
+					closed = !m.at(position{x, y}).openings[north]
+				} else {
+					closed = !m.at(position{x, y - 1}).openings[south]
+				}
+			}
+
+			if closed && run < 0 {
+				run = x
+			} else if !closed && run >= 0 {
+				segments = append(segments, segment{run, y, x, y})
+				run = -1
+			}
+		}
+	}
+
+	// Vertical walls, likewise.
+	for x := 0; x <= m.width; x++ {
+		run := -1
+		for y := 0; y <= m.height; y++ {
+			closed := false
+			if y < m.height {
+				if x < m.width {
+					closed = !m.at(position{x, y}).openings[west]
+				} else {
+					closed = !m.at(position{x - 1, y}).openings[east]
+				}
+			}
+
+			if closed && run < 0 {
+				run = y
+			} else if !closed && run >= 0 {
+				segments = append(segments, segment{x, run, x, y})
+				run = -1
+			}
+		}
+	}
+
+	return segments
+}
+
+// Render the maze as an SVG document, using the same geometry as the
+// raster renderer. If path is not nil, it is drawn as the solution in
+// the given style.
+func (m *maze) svg(path []position, style string) string {
+	return m.svgPage(path, style, pageSetup{})
+}
+
+// Render the maze as an SVG document laid out for printing. Sized to
+// the maze, its units are CSS pixels; on paper, they're millimetres.
+func (m *maze) svgPage(path []position, style string, page pageSetup) string {
+	defer tr(ace("rendering svg"))
+
+	width, height := m.imageSize()
+	_, onPaper := paperSizes[page.paper]
+	unitsPerMM := cssPixelsPerMM
+	if onPaper {
+		unitsPerMM = 1
+	}
+	l := page.layout(width, height, unitsPerMM)
+
+	var b strings.Builder
+	if onPaper {
+		fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%gmm" height="%gmm" viewBox="0 0 %g %g">`+"\n", l.width, l.height, l.width, l.height)
+		fmt.Fprintf(&b, `<g transform="translate(%.3f %.3f) scale(%.5f)">`+"\n", l.x, l.y, l.scale)
+	} else {
+		fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	}
+	b.WriteString(m.svgContent(path, style, l.stroke))
+	if onPaper {
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// SVG path data for the given path, through the centers of its cells
+// in image pixels, for pages that draw or animate along the solution
+// themselves.
+func (m *maze) svgPathData(path []position) string {
+	var b strings.Builder
+	for i, p := range path {
+		cmd := "L"
+		if i == 0 {
+			cmd = "M"
+		}
+		fmt.Fprintf(&b, "%s%d %d", cmd, p.x*cellWidth+border+halfCellWidth, p.y*cellWidth+border+halfCellWidth)
+	}
+	return b.String()
+}
+
+// Colors SVG has names for, which it's written with when the theme
+// has them, as the default theme does.
+var svgColorNames = map[color.RGBA]string{
+	{0, 0, 0, 255}:       "black",
+	{255, 255, 255, 255}: "white",
+	{255, 0, 0, 255}:     "red",
+}
+
+// A color as SVG writes it.
+func svgColor(c color.RGBA) string {
+	if name, ok := svgColorNames[c]; ok {
+		return name
+	}
+	return cssColor(c)
+}
+
+// The elements of the maze's SVG, without the document around them,
+// with walls of the given width, in the theme's colors.
+func (m *maze) svgContent(path []position, style string, stroke float64) string {
+	width, height := m.imageSize()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, svgColor(currentTheme.background))
+
+	fmt.Fprintf(&b, `<path fill="none" stroke="%s" stroke-linecap="square"`, svgColor(currentTheme.wall))
+	if stroke != 1 {
+		fmt.Fprintf(&b, ` stroke-width="%.3f"`, stroke)
+	}
+	b.WriteString(` d="`)
+	for _, s := range m.walls() {
+		fmt.Fprintf(&b, "M%d %d", s.x0*cellWidth+border, s.y0*cellWidth+border)
+		if s.y0 == s.y1 {
+			fmt.Fprintf(&b, "H%d", s.x1*cellWidth+border)
+		} else {
+			fmt.Fprintf(&b, "V%d", s.y1*cellWidth+border)
+		}
+	}
+	b.WriteString(`"/>` + "\n")
+
+	if len(path) > 0 {
+		stroke, ok := svgPathStyles[style]
+		if !ok {
+			stroke = fmt.Sprintf(`stroke="%s"`, svgColor(currentTheme.solution))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" %s points="`, stroke)
+		for i, p := range path {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(&b, "%d,%d", p.x*cellWidth+border+halfCellWidth, p.y*cellWidth+border+halfCellWidth)
+		}
+		b.WriteString(`"/>` + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Games built on the generator need to check that a player really
+// solved the maze they claim to have, for leaderboards. Mazes are
+// deterministic, so a maze ID naming its size, seed, and start
+// placement is all it takes to rebuild it and replay the player's
+// moves through it.
+//
+// Maze IDs look like "15x20-1f3a", height by width then the seed in
+// hex, with "-o" on the end if the start and finish are in opposite
+// corners and then the algorithm's key (say "-prim") if it isn't the
+// recursive backtracker. Moves are a string of the letters N, S, E,
+// and W.
+//
+// Mazes changed after generation, by decoys, braiding, extra
+// solutions, or a mask, aren't what their IDs rebuild, so they have no
+// ID to verify against; their maze codes (see codec.go) do instead.
+
+var (
+	badMazeID      = errors.New("malformed maze id")
+	badMove        = errors.New("unknown move")
+	illegalMove    = errors.New("move goes through a wall")
+	unfinishedPath = errors.New("moves don't reach the finish")
+)
+
+var moveLetters = map[rune]direction{
+	'N': north,
+	'S': south,
+	'E': east,
+	'W': west,
+}
+
+// The ID of a maze built with the given settings.
+func mazeID(height, width int, seed int64, oppositeStart bool, algorithm string) string {
+	id := fmt.Sprintf("%dx%d-%x", height, width, uint64(seed))
+	if oppositeStart {
+		id += "-o"
+	}
+	for _, g := range generators {
+		if g.name == algorithm && g.key != "" {
+			id += "-" + g.key
+		}
+	}
+	return id
+}
+
+// Read the parameters a maze ID names.
+func parseMazeID(id string) (height, width int, seed int64, oppositeStart bool, algorithm string, err error) {
+	parts := strings.Split(id, "-")
+	if len(parts) < 2 {
+		return 0, 0, 0, false, "", badMazeID
+	}
+	rest := parts[2:]
+	if len(rest) > 0 && rest[0] == "o" {
+		oppositeStart, rest = true, rest[1:]
+	}
+	algorithm = generatorAlgorithm
+	if len(rest) > 0 {
+		algorithm = ""
+		for _, g := range generators {
+			if g.key != "" && g.key == rest[0] {
+				algorithm, rest = g.name, rest[1:]
+				break
+			}
+		}
+	}
+	if algorithm == "" || len(rest) > 0 {
+		return 0, 0, 0, false, "", badMazeID
+	}
+
+	size := strings.Split(parts[0], "x")
+	if len(size) != 2 {
+		return 0, 0, 0, false, "", badMazeID
+	}
+	height, err = strconv.Atoi(size[0])
+	if err != nil {
+		return 0, 0, 0, false, "", badMazeID
+	}
+	width, err = strconv.Atoi(size[1])
+	if err != nil || height < 2 || width < 2 || height > maxDimension || width > maxDimension {
+		return 0, 0, 0, false, "", badMazeID
+	}
+	u, err := strconv.ParseUint(parts[1], 16, 64)
+	if err != nil {
+		return 0, 0, 0, false, "", badMazeID
+	}
+	return height, width, int64(u), oppositeStart, algorithm, nil
+}
+
+// Whether the maze was changed after generation, so that its ID names
+// the maze it was generated as rather than the maze it is.
+func (m *maze) reshaped() bool {
+	return m.loops || m.decoys || m.mask != nil
+}
+
+// The ID of the maze, generated from the given seed, or "" if it was
+// reshaped and so has none.
+func (m *maze) id(seed int64) string {
+	if m.reshaped() {
+		return ""
+	}
+	return mazeID(m.height, m.width, seed, m.oppositeStart, m.algorithm)
+}
+
+// Rebuild the maze a maze ID names, or that a maze code carries.
+func mazeFromID(id string) (*maze, error) {
+	height, width, seed, oppositeStart, algorithm, err := parseMazeID(id)
+	if err != nil {
+		if m, _, err := decodeMaze(id); err == nil {
+			return m, nil
+		}
+		return nil, badMazeID
+	}
+
+	m := newMaze(height, width, rand.New(rand.NewSource(seed)), oppositeStart)
+	m.generateWith(algorithm)
+	return m, nil
+}
+
+// Check that the moves are a legal, complete solution of the maze with
+// the given ID or code: that they start at the start, never go through
+// a wall, and end at the finish.
+func verifySolution(id, moves string) error {
+	m, err := mazeFromID(id)
+	if err != nil {
+		return err
+	}
+
+	pos := m.start
+	for _, r := range strings.ToUpper(moves) {
+		d, ok := moveLetters[r]
+		if !ok {
+			return badMove
+		}
+		np, err := d.translate(pos, m)
+		if err != nil || !m.at(pos).openings[d] {
+			return illegalMove
+		}
+		pos = np
+	}
+
+	if pos != m.finish {
+		return unfinishedPath
+	}
+	return nil
+}
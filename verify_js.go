@@ -0,0 +1,54 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// The JS side of verification; see verify.go.
+
+// The ID of the most recently generated maze, or "" if it has none;
+// see (*maze).id.
+var currentID = ""
+
+// The current maze's ID for JS: null if it has none.
+func currentIDJS() interface{} {
+	if currentID == "" {
+		return js.Null()
+	}
+	return currentID
+}
+
+func verifyJS(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return badMazeID.Error()
+	}
+	if err := verifySolution(args[0].String(), args[1].String()); err != nil {
+		return err.Error()
+	}
+	return js.Null()
+}
+
+func mazeIDJS(this js.Value, args []js.Value) interface{} {
+	if currentMaze == nil {
+		return js.Null()
+	}
+	return currentIDJS()
+}
+
+// Build the JS-facing verification API. verify(id, moves) returns null
+// if the moves solve the maze with the given ID or code, or why they
+// don't; id() returns the ID of the current maze, or null if it has
+// none.
+func verifyControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	verify := js.FuncOf(verifyJS)
+	controls.Set("verify", verify)
+
+	id := js.FuncOf(mazeIDJS)
+	controls.Set("id", id)
+
+	return controls, []js.Func{verify, id}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// Big mazes are cheap to generate but dear to draw: at the default
+// theme a maze 2000 cells on a side has an image 24,080 pixels on a
+// side, over two gigabytes of frame buffer. So mazes bigger than
+// maxDrawnCells are only drawn through a viewport, a frame buffer
+// of a fixed size showing part of their image at some offset and zoom.
+// Every pixel of it is worked out from the maze's cells alone, so
+// drawing costs the same however big the maze is, and the page pans
+// and zooms by asking for the viewport to be drawn again.
+
+const (
+	viewportWidth  = 960 // Size (in pixels) of a viewport's frame buffer
+	viewportHeight = 720
+	maxViewZoom    = 8 // Frame buffer pixels to an image pixel, at most
+)
+
+// Most cells on a side of a maze drawn whole at the current theme: at
+// most maxDrawnDimension, and few enough that the image is at most
+// maxDrawnSide pixels on a side.
+func maxDrawnCells() int {
+	return clampInt((maxDrawnSide-border*2)/cellWidth, 2, maxDrawnDimension)
+}
+
+// Whether a maze of the given size can be drawn whole at the current
+// theme.
+func drawnWhole(height, width int) bool {
+	n := maxDrawnCells()
+	return height <= n && width <= n
+}
+
+// Whether the maze is too big to draw whole.
+func (m *maze) viewOnly() bool {
+	return !drawnWhole(m.height, m.width)
+}
+
+// Part of a maze's image, as shown in a frame buffer.
+type viewport struct {
+	x, y          float64 // The image point (in pixels) at the frame buffer's top left
+	zoom          float64 // Frame buffer pixels to an image pixel
+	width, height int     // Of the frame buffer (in pixels)
+}
+
+// The viewport onto the maze's image at zoom 1, centred on its start.
+func (m *maze) startView() viewport {
+	v := viewport{zoom: 1, width: viewportWidth, height: viewportHeight}
+	v.x = float64(border+m.start.x*cellWidth+halfCellWidth) - float64(v.width)/2
+	v.y = float64(border+m.start.y*cellWidth+halfCellWidth) - float64(v.height)/2
+	return m.clampView(v)
+}
+
+// Keep the viewport on the maze's image, between showing the whole of
+// it and maxViewZoom. Images smaller than the viewport are centred.
+func (m *maze) clampView(v viewport) viewport {
+	width, height := m.imageSize()
+	fit := math.Min(float64(v.width)/float64(width), float64(v.height)/float64(height))
+	v.zoom = math.Max(math.Min(v.zoom, maxViewZoom), math.Min(fit, maxViewZoom))
+
+	clamp := func(at float64, image int, frame int) float64 {
+		shown := float64(frame) / v.zoom
+		if shown >= float64(image) {
+			return (float64(image) - shown) / 2
+		}
+		return math.Max(0, math.Min(at, float64(image)-shown))
+	}
+	v.x, v.y = clamp(v.x, width, v.width), clamp(v.y, height, v.height)
+	return v
+}
+
+// Move the viewport by the given number of frame buffer pixels.
+func (m *maze) panView(v viewport, dx, dy float64) viewport {
+	v.x += dx / v.zoom
+	v.y += dy / v.zoom
+	return m.clampView(v)
+}
+
+// Zoom the viewport by the given factor, keeping the image point under
+// the given frame buffer point where it is.
+func (m *maze) zoomView(v viewport, factor, x, y float64) viewport {
+	if factor <= 0 {
+		return v
+	}
+	ix, iy := v.x+x/v.zoom, v.y+y/v.zoom
+	v.zoom *= factor
+	v.x, v.y = ix-x/v.zoom, iy-y/v.zoom
+	return m.clampView(v)
+}
+
+// Which way the path leaves each cell for a neighbor east or south of
+// it, indexed like m.cells; the two kinds of step a path's drawn with.
+func (m *maze) pathSteps(path []position) []uint8 {
+	if path == nil {
+		return nil
+	}
+	steps := make([]uint8, len(m.cells))
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		if b.x < a.x || b.y < a.y {
+			a, b = b, a
+		}
+		if b.x > a.x {
+			steps[a.y*m.width+a.x] |= 1 << east
+		} else {
+			steps[a.y*m.width+a.x] |= 1 << south
+		}
+	}
+	return steps
+}
+
+// Draw what the viewport shows of the maze's image into a frame
+// buffer of its size, with the path whose steps are given, if any.
+// Lines are as thick as the theme says, but never thinner than a
+// pixel of the frame buffer, so zoomed out walls still show.
+func (m *maze) drawView(img *image.RGBA, v viewport, steps []uint8) {
+	defer tr(ace("drawing viewport"))
+
+	before, after := lineReach()
+	lo, hi := -float64(before), float64(after+1)
+	if hi-lo < 1/v.zoom {
+		hi = lo + 1/v.zoom
+	}
+	off := float64(halfCellWidth)
+
+	wallV := func(k, r int) bool { return m.walled(position{k, r}, west) || m.walled(position{k - 1, r}, east) }
+	wallH := func(k, c int) bool { return m.walled(position{c, k}, north) || m.walled(position{c, k - 1}, south) }
+	pathV := func(c, r int) bool { return steps[r*m.width+c]&(1<<south) != 0 }
+	pathH := func(r, c int) bool { return steps[r*m.width+c]&(1<<east) != 0 }
+
+	for py := 0; py < v.height; py++ {
+		y := v.y + (float64(py)+0.5)/v.zoom - float64(border)
+		for px := 0; px < v.width; px++ {
+			x := v.x + (float64(px)+0.5)/v.zoom - float64(border)
+
+			c := currentTheme.background
+			switch {
+			case steps != nil && (onLines(x-off, y-off, lo, hi, m.width, m.height-1, pathV) || onLines(y-off, x-off, lo, hi, m.height, m.width-1, pathH)):
+				c = currentTheme.solution
+			case onLines(x, y, lo, hi, m.width+1, m.height, wallV) || onLines(y, x, lo, hi, m.height+1, m.width, wallH):
+				c = currentTheme.wall
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// Whether the cell has a wall on the given side; cells outside the
+// maze or its mask have none.
+func (m *maze) walled(p position, d direction) bool {
+	if p.x < 0 || p.y < 0 || p.x >= m.width || p.y >= m.height || !m.inMask(p) {
+		return false
+	}
+	return !m.at(p).openings[d]
+}
+
+// Whether a point is on one of a set of parallel lines, a cell apart,
+// each made of segments a cell long, as hLine and vLine would draw
+// them: across is the point's distance across the lines from the
+// first, and along its distance along them from the start of their
+// first segments, in image pixels. Lines reach from lo to hi either
+// side of where they're drawn, and past their ends likewise. segment
+// says whether the given segment of the given line is there.
+func onLines(across, along, lo, hi float64, lines, segments int, segment func(line, i int) bool) bool {
+	cell := float64(cellWidth)
+	first, last := int(math.Floor((across-hi)/cell))+1, int(math.Floor((across-lo)/cell))
+	from, to := int(math.Floor((along-hi)/cell)), int(math.Floor((along-lo)/cell))
+	for k := clampInt(first, 0, lines); k <= last && k < lines; k++ {
+		for i := clampInt(from, 0, segments); i <= to && i < segments; i++ {
+			if segment(k, i) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"image"
+	"syscall/js"
+)
+
+// Mazes too big to draw whole are drawn through a viewport (see
+// viewport.go), which the page pans and zooms with MazeGen.view,
+// MazeGen.pan, and MazeGen.zoom. It starts at zoom 1 around the
+// entrance. Only the walls and the solution are drawn through it, with
+// no label, and the maze can't be exported whole (see exportable).
+
+// The viewport the current maze is drawn through, if it's too big to
+// draw whole, and the steps of the solution it shows, if any.
+var (
+	currentView *viewport = nil
+	viewSteps   []uint8   = nil
+)
+
+// Draw the current maze through a new viewport onto its entrance.
+func renderView(m *maze) {
+	defer tr(ace("rendering viewport"))
+
+	showGLCanvas(false)
+	resetCanvasStyle()
+	v := m.startView()
+	currentView, viewSteps = &v, m.pathSteps(currentSolution)
+	drawCurrentView()
+}
+
+// Draw the current maze through the current viewport again, reusing
+// the frame buffer if it's the viewport's size.
+func drawCurrentView() {
+	bounds := image.Rect(0, 0, currentView.width, currentView.height)
+	img, ok := frameBuffer.(*image.RGBA)
+	if !ok || img.Bounds() != bounds {
+		img = image.NewRGBA(bounds)
+	}
+	currentMaze.drawView(img, *currentView, viewSteps)
+	frameBuffer, frameBufferMaze = img, nil
+	export("")
+	if f := js.Global().Get("disableWholeExports"); f.Type() == js.TypeFunction {
+		f.Invoke()
+	}
+}
+
+// Move the current viewport to v, and draw it, returning it as a
+// MazeViewport.
+func showView(v viewport) interface{} {
+	*currentView = currentMaze.clampView(v)
+	drawCurrentView()
+	return viewToJS(*currentView)
+}
+
+func viewToJS(v viewport) map[string]interface{} {
+	imageWidth, imageHeight := currentMaze.imageSize()
+	return map[string]interface{}{
+		"x":           v.x,
+		"y":           v.y,
+		"zoom":        v.zoom,
+		"width":       v.width,
+		"height":      v.height,
+		"imageWidth":  imageWidth,
+		"imageHeight": imageHeight,
+	}
+}
+
+func viewJS(this js.Value, args []js.Value) interface{} {
+	if currentView == nil || currentMaze == nil {
+		return js.Null()
+	}
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return viewToJS(*currentView)
+	}
+	v := *currentView
+	if x, ok := finiteArg(args[0].Get("x")); ok {
+		v.x = x
+	}
+	if y, ok := finiteArg(args[0].Get("y")); ok {
+		v.y = y
+	}
+	if zoom, ok := finiteArg(args[0].Get("zoom")); ok && zoom > 0 {
+		v.zoom = zoom
+	}
+	return showView(v)
+}
+
+func panJS(this js.Value, args []js.Value) interface{} {
+	if currentView == nil || currentMaze == nil || len(args) < 2 {
+		return js.Null()
+	}
+	dx, okX := finiteArg(args[0])
+	dy, okY := finiteArg(args[1])
+	if !okX || !okY {
+		return false
+	}
+	return showView(currentMaze.panView(*currentView, dx, dy))
+}
+
+func zoomJS(this js.Value, args []js.Value) interface{} {
+	if currentView == nil || currentMaze == nil || len(args) < 1 {
+		return js.Null()
+	}
+	factor, ok := finiteArg(args[0])
+	if !ok || factor <= 0 {
+		return false
+	}
+	x, y := float64(currentView.width)/2, float64(currentView.height)/2
+	if len(args) >= 3 {
+		atX, okX := finiteArg(args[1])
+		atY, okY := finiteArg(args[2])
+		if !okX || !okY {
+			return false
+		}
+		x, y = atX, atY
+	}
+	return showView(currentMaze.zoomView(*currentView, factor, x, y))
+}
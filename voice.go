@@ -0,0 +1,86 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Voice commands. These are meant to be fed the transcripts the Web
+// Speech API produces, so they're forgiving: filler words are ignored,
+// relative and compass directions both work, and a few words speech
+// recognition commonly mishears for directions are accepted too.
+
+const maxRepeat = 20 // Most times a single command will repeat a move
+
+var directionWords = map[string]direction{
+	"north": north,
+	"up":    north,
+	"south": south,
+	"down":  south,
+	"east":  east,
+	"right": east,
+	"write": east,
+	"west":  west,
+	"left":  west,
+	"lift":  west,
+}
+
+var countWords = map[string]int{
+	"once":   1,
+	"one":    1,
+	"twice":  2,
+	"two":    2,
+	"thrice": 3,
+	"three":  3,
+	"four":   4,
+	"five":   5,
+	"six":    6,
+	"seven":  7,
+	"eight":  8,
+	"nine":   9,
+	"ten":    10,
+}
+
+// Carry out a command such as "go left twice", "up 3 then right", or
+// "north, east, east". Each direction may be followed by a count.
+// Repeated moves stop at the first wall. Returns the number of moves
+// made.
+func (g *game) command(text string) int {
+	var dirs []direction
+	var counts []int
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	}) {
+		if d, ok := directionWords[strings.TrimSuffix(word, "s")]; ok {
+			dirs = append(dirs, d)
+			counts = append(counts, 1)
+			continue
+		}
+
+		count, ok := countWords[word]
+		if n, err := strconv.Atoi(word); err == nil {
+			count, ok = n, true
+		}
+		if ok && len(counts) > 0 {
+			counts[len(counts)-1] = count
+		}
+	}
+
+	moves := 0
+	for i, d := range dirs {
+		count := counts[i]
+		if count > maxRepeat {
+			count = maxRepeat
+		}
+		for j := 0; j < count; j++ {
+			if !g.play(d) {
+				return moves
+			}
+			moves++
+		}
+	}
+	return moves
+}
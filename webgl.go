@@ -0,0 +1,258 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"math"
+	"syscall/js"
+)
+
+// The WebGL renderer uploads the maze's walls (and solution) to the
+// GPU once, as lines, and from then on redrawing is a single draw
+// call. Zooming, panning, and revealing the solution only change a
+// few uniforms, rather than re-rasterizing the whole frame buffer in
+// WASM and copying it out.
+
+const (
+	webGLRenderer = "webgl" // Renderer argument selecting this renderer
+	maxGLCanvas   = 4096    // Largest width/height (in pixels) of the WebGL canvas
+)
+
+const glVertexShader = `
+attribute vec2 position;
+uniform vec2 scale;
+uniform vec2 offset;
+void main() {
+	gl_Position = vec4(position * scale + offset, 0.0, 1.0);
+}
+`
+
+const glFragmentShader = `
+precision mediump float;
+uniform vec4 color;
+void main() {
+	gl_FragColor = color;
+}
+`
+
+var noWebGL = errors.New("webgl unavailable")
+
+type glRenderer struct {
+	gl, canvas       js.Value
+	program          js.Value
+	position         js.Value // Attribute location
+	scale, offset    js.Value // Uniform locations
+	color            js.Value // ...
+	walls, path      js.Value // Vertex buffers
+	wallVertices     int
+	pathShown        int     // Number of path vertices to draw
+	width, height    float64 // Size of the maze image (in pixels)
+	zoom, panX, panY float64 // The view; pan is in image pixels
+	canvasW, canvasH float64
+}
+
+// The WebGL renderer, created the first time it's used.
+var glView *glRenderer = nil
+
+// Whether the WebGL canvas is showing in place of the raster one. The
+// raster canvas is what's exported as an image, and it doesn't have
+// the maze on it then.
+var glShown = false
+
+func newGLRenderer() (*glRenderer, error) {
+	canvas := element("glCanvas")
+	if canvas.IsNull() || canvas.IsUndefined() {
+		return nil, noWebGL
+	}
+	gl := canvas.Call("getContext", "webgl")
+	if gl.IsNull() || gl.IsUndefined() {
+		return nil, noWebGL
+	}
+
+	compile := func(kind, source string) (js.Value, error) {
+		shader := gl.Call("createShader", gl.Get(kind))
+		gl.Call("shaderSource", shader, source)
+		gl.Call("compileShader", shader)
+		if !gl.Call("getShaderParameter", shader, gl.Get("COMPILE_STATUS")).Truthy() {
+			return js.Null(), errors.New(gl.Call("getShaderInfoLog", shader).String())
+		}
+		return shader, nil
+	}
+
+	vs, err := compile("VERTEX_SHADER", glVertexShader)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := compile("FRAGMENT_SHADER", glFragmentShader)
+	if err != nil {
+		return nil, err
+	}
+
+	program := gl.Call("createProgram")
+	gl.Call("attachShader", program, vs)
+	gl.Call("attachShader", program, fs)
+	gl.Call("linkProgram", program)
+	if !gl.Call("getProgramParameter", program, gl.Get("LINK_STATUS")).Truthy() {
+		return nil, errors.New(gl.Call("getProgramInfoLog", program).String())
+	}
+
+	return &glRenderer{
+		gl:       gl,
+		canvas:   canvas,
+		program:  program,
+		position: gl.Call("getAttribLocation", program, "position"),
+		scale:    gl.Call("getUniformLocation", program, "scale"),
+		offset:   gl.Call("getUniformLocation", program, "offset"),
+		color:    gl.Call("getUniformLocation", program, "color"),
+		walls:    gl.Call("createBuffer"),
+		path:     gl.Call("createBuffer"),
+		zoom:     1,
+	}, nil
+}
+
+// Copy float32s out of linear memory into a new Float32Array.
+func float32sToJS(fs []float32) js.Value {
+	b := make([]byte, len(fs)*4)
+	for i, f := range fs {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+	}
+	return js.Global().Get("Float32Array").New(bytesToJS(b).Get("buffer"))
+}
+
+// Upload the maze's geometry, in the same pixel coordinates the raster
+// renderer uses, and reset the view. The whole path is shown.
+func (r *glRenderer) load(m *maze, path []position) {
+	defer tr(ace("uploading geometry"))
+
+	var walls []float32
+	for _, s := range m.walls() {
+		walls = append(walls,
+			float32(s.x0*cellWidth+border), float32(s.y0*cellWidth+border),
+			float32(s.x1*cellWidth+border), float32(s.y1*cellWidth+border),
+		)
+	}
+
+	var points []float32
+	for _, p := range path {
+		points = append(points,
+			float32(p.x*cellWidth+border+halfCellWidth), float32(p.y*cellWidth+border+halfCellWidth),
+		)
+	}
+
+	gl := r.gl
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), r.walls)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), float32sToJS(walls), gl.Get("STATIC_DRAW"))
+	gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), r.path)
+	gl.Call("bufferData", gl.Get("ARRAY_BUFFER"), float32sToJS(points), gl.Get("STATIC_DRAW"))
+	r.wallVertices = len(walls) / 2
+	r.pathShown = len(path)
+
+	width, height := m.imageSize()
+	r.width, r.height = float64(width), float64(height)
+
+	// Keep the canvas a manageable size; the view scales to fit it.
+	fit := math.Min(1, maxGLCanvas/math.Max(r.width, r.height))
+	r.canvasW, r.canvasH = math.Floor(r.width*fit), math.Floor(r.height*fit)
+	r.canvas.Set("width", r.canvasW)
+	r.canvas.Set("height", r.canvasH)
+	r.zoom, r.panX, r.panY = 1, 0, 0
+}
+
+// Set the view: zoom is relative to fitting the whole maze on the
+// canvas, and the pan is the image pixel at the canvas's top left.
+func (r *glRenderer) setView(zoom, panX, panY float64) {
+	if zoom > 0 {
+		r.zoom = zoom
+	}
+	r.panX, r.panY = panX, panY
+	r.draw()
+}
+
+func (r *glRenderer) draw() {
+	gl := r.gl
+	gl.Call("viewport", 0, 0, r.canvasW, r.canvasH)
+	bg := currentTheme.background
+	gl.Call("clearColor", float64(bg.R)/255, float64(bg.G)/255, float64(bg.B)/255, 1)
+	gl.Call("clear", gl.Get("COLOR_BUFFER_BIT"))
+	gl.Call("useProgram", r.program)
+
+	// Map image pixels to clip space, flipping Y.
+	z := r.zoom
+	gl.Call("uniform2f", r.scale, 2*z/r.width, -2*z/r.height)
+	gl.Call("uniform2f", r.offset, -1-2*z*r.panX/r.width, 1+2*z*r.panY/r.height)
+
+	lines := func(buffer js.Value, mode string, count int, c color.RGBA) {
+		gl.Call("bindBuffer", gl.Get("ARRAY_BUFFER"), buffer)
+		gl.Call("enableVertexAttribArray", r.position)
+		gl.Call("vertexAttribPointer", r.position, 2, gl.Get("FLOAT"), false, 0, 0)
+		gl.Call("uniform4f", r.color, float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, 1)
+		gl.Call("drawArrays", gl.Get(mode), 0, count)
+	}
+
+	lines(r.walls, "LINES", r.wallVertices, currentTheme.wall)
+	if r.pathShown > 1 {
+		lines(r.path, "LINE_STRIP", r.pathShown, currentTheme.solution)
+	}
+}
+
+// Render the maze with WebGL, animating the solution if asked.
+func renderGL(m *maze, path []position, animated bool, duration float64) error {
+	if glView == nil {
+		r, err := newGLRenderer()
+		if err != nil {
+			return err
+		}
+		glView = r
+	}
+
+	glView.load(m, path)
+	showGLCanvas(true)
+	for _, name := range []string{"enableExports", "disableCanvasExports"} {
+		if f := js.Global().Get(name); f.Type() == js.TypeFunction {
+			f.Invoke()
+		}
+	}
+
+	if animated && len(path) > 1 {
+		glView.pathShown = 1
+		animate(newAnimation(len(path)-1, duration,
+			func() { glView.pathShown = 1 },
+			func(i int) { glView.pathShown = i + 2 },
+			glView.draw,
+		))
+		return nil
+	}
+
+	glView.draw()
+	return nil
+}
+
+// Show the WebGL canvas, or the raster one.
+func showGLCanvas(show bool) {
+	glShown = show
+	gl, raster := "none", ""
+	if show {
+		gl, raster = "", "none"
+	}
+	if glCanvas := element("glCanvas"); !glCanvas.IsNull() {
+		glCanvas.Get("style").Set("display", gl)
+	}
+	targetCanvas.Get("style").Set("display", raster)
+}
+
+// Build the JS-facing WebGL view controls.
+func glControls() (js.Value, []js.Func) {
+	controls := js.Global().Get("Object").New()
+	setView := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if glView != nil && len(args) == 3 {
+			glView.setView(args[0].Float(), args[1].Float(), args[2].Float())
+		}
+		return nil
+	})
+	controls.Set("setView", setView)
+	return controls, []js.Func{setView}
+}
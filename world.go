@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+)
+
+// A world is a set of mazes, or rooms, joined by portals. Stepping
+// onto a portal shows the room it leads to and puts the player on the
+// portal at the other end. Each room has a portal to the next, and the
+// game is won at the finish of the last room. Enemies and checkpoints
+// are only placed in the room the game starts in, and are left behind
+// when the player steps through a portal.
+
+const (
+	maxRooms         = 10       // Most rooms in a world
+	portalPlacements = 50       // Tries at placing each portal
+	portalEvent      = "portal" // Game event: the player went through a portal
+)
+
+// The portals' color.
+var purple = image.NewUniform(color.RGBA{128, 0, 192, 255})
+
+// One end of a portal.
+type portal struct {
+	room int
+	at   position
+	to   int // The other end; an index into the world's portals
+}
+
+type world struct {
+	rooms   []*maze
+	portals []portal
+}
+
+// The world being played, if any.
+var currentWorld *world = nil
+
+// Generate a world of the given number of rooms, starting with the
+// given maze. The other rooms are the same size, and are generated
+// with its RNG.
+func newWorld(first *maze, oppositeStart bool, rooms int) *world {
+	defer tr(ace("generating world"))
+
+	if rooms > maxRooms {
+		rooms = maxRooms
+	}
+	rng := first.rng
+	w := &world{rooms: []*maze{first}}
+	for i := 1; i < rooms; i++ {
+		m := newMaze(first.height, first.width, rng, oppositeStart)
+		m.generateWith(first.algorithm)
+		w.rooms = append(w.rooms, m)
+	}
+
+	for i := 0; i+1 < rooms; i++ {
+		a, b := len(w.portals), len(w.portals)+1
+		w.portals = append(w.portals,
+			portal{room: i, at: w.place(i, rng), to: b},
+			portal{room: i + 1, at: w.place(i+1, rng), to: a},
+		)
+	}
+	return w
+}
+
+// Find a free cell for a portal in the given room: not its start or
+// finish, nor another portal.
+func (w *world) place(room int, rng *rand.Rand) position {
+	m := w.rooms[room]
+	var p position
+	for try := 0; try < portalPlacements; try++ {
+		p = position{x: rng.Intn(m.width), y: rng.Intn(m.height)}
+		if p != m.start && p != m.finish && w.portalAt(room, p) < 0 {
+			break
+		}
+	}
+	return p
+}
+
+// The index of the portal in the given room's cell, or -1 if there's
+// none.
+func (w *world) portalAt(room int, p position) int {
+	for i, end := range w.portals {
+		if end.room == room && end.at == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// Which room of the world a maze is, or -1 if it's not one of them.
+func (w *world) roomOf(m *maze) int {
+	for i, room := range w.rooms {
+		if room == m {
+			return i
+		}
+	}
+	return -1
+}
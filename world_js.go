@@ -0,0 +1,70 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"image/draw"
+)
+
+// Play the game in the given world, if the game's maze is one of its
+// rooms.
+func (g *game) enter(w *world) {
+	if room := w.roomOf(g.m); room >= 0 {
+		g.world, g.room = w, room
+	}
+}
+
+// If the player is on a portal, take them through it, showing the
+// room on the other side. Returns whether they went through.
+func (g *game) teleport() bool {
+	if g.world == nil {
+		return false
+	}
+	i := g.world.portalAt(g.room, g.player)
+	if i < 0 {
+		return false
+	}
+
+	g.stopEnemies()
+	g.enemies, g.checkpoints, g.reached = nil, nil, nil
+
+	end := g.world.portals[g.world.portals[i].to]
+	g.room, g.m, g.player = end.room, g.world.rooms[end.room], end.at
+	g.respawnAt = g.player
+	g.distances = g.m.distances(g.m.finish)
+	if currentSolution != nil {
+		currentSolution = g.m.solution()
+	}
+	currentMaze = g.m
+	g.emit(portalEvent)
+	g.redraw()
+	g.follow()
+	announce(fmt.Sprintf("Through the portal to room %d of %d. %s", g.room+1, len(g.world.rooms), g.describeExits()))
+	return true
+}
+
+// Whether the player has won: reached the finish, of the last room if
+// they're in a world.
+func (g *game) finished() bool {
+	return g.player == g.m.finish && (g.world == nil || g.room == len(g.world.rooms)-1)
+}
+
+// Draw the portals in the player's room as rings.
+func (g *game) drawPortals(img draw.Image) {
+	if g.world == nil {
+		return
+	}
+	for _, end := range g.world.portals {
+		if end.room != g.room {
+			continue
+		}
+		x := end.at.x*cellWidth + border + cellWidth/4
+		y := end.at.y*cellWidth + border + cellWidth/4
+		hLine(img, x, y, x+halfCellWidth, purple)
+		hLine(img, x, y+halfCellWidth, x+halfCellWidth, purple)
+		vLine(img, x, y, y+halfCellWidth, purple)
+		vLine(img, x+halfCellWidth, y, y+halfCellWidth, purple)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A spreadsheet of the maze has a row per row of cells and a column
+// per column, all square, with borders for walls, so it can be printed
+// (on one page) from Excel or Google Sheets. The start and finish are
+// marked S and F. Each of the sixteen combinations of walls a cell can
+// have is its own style, numbered by the same bits as mazeToJS uses.
+
+const (
+	xlsxColumnWidth = 2.14 // Column width (in characters), about 20 pixels
+	xlsxRowHeight   = 15   // Row height (in points), 20 pixels
+)
+
+const xlsxMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+const xlsxRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+// The fixed parts of the workbook, by file name.
+var xlsxParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="` + xlsxRelationships + `/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + xlsxMain + `" xmlns:r="` + xlsxRelationships + `">
+<sheets><sheet name="Maze" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="` + xlsxRelationships + `/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="` + xlsxRelationships + `/styles" Target="styles.xml"/>
+</Relationships>`,
+}
+
+// The stylesheet: a border, and a cell style using it, for every
+// combination of walls.
+func xlsxStyles() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	fmt.Fprintf(&b, `<styleSheet xmlns="%s">`+"\n", xlsxMain)
+	b.WriteString(`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` + "\n")
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` + "\n")
+	b.WriteString(`<borders count="16">`)
+	side := func(name string, wall bool) string {
+		if wall {
+			return fmt.Sprintf(`<%s style="medium"><color rgb="FF000000"/></%s>`, name, name)
+		}
+		return "<" + name + "/>"
+	}
+	for walls := 0; walls < 16; walls++ {
+		b.WriteString("<border>")
+		b.WriteString(side("left", walls&8 != 0))
+		b.WriteString(side("right", walls&4 != 0))
+		b.WriteString(side("top", walls&1 != 0))
+		b.WriteString(side("bottom", walls&2 != 0))
+		b.WriteString("<diagonal/></border>")
+	}
+	b.WriteString("</borders>\n")
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` + "\n")
+	b.WriteString(`<cellXfs count="16">`)
+	for walls := 0; walls < 16; walls++ {
+		fmt.Fprintf(&b, `<xf numFmtId="0" fontId="0" fillId="0" borderId="%d" xfId="0" applyBorder="1" applyAlignment="1"><alignment horizontal="center"/></xf>`, walls)
+	}
+	b.WriteString("</cellXfs>\n</styleSheet>")
+	return b.String()
+}
+
+// The worksheet.
+func (m *maze) xlsxSheet() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	fmt.Fprintf(&b, `<worksheet xmlns="%s" xmlns:r="%s">`+"\n", xlsxMain, xlsxRelationships)
+	b.WriteString(`<sheetPr><pageSetUpPr fitToPage="1"/></sheetPr>` + "\n")
+	fmt.Fprintf(&b, `<cols><col min="1" max="%d" width="%g" customWidth="1"/></cols>`+"\n", m.width, xlsxColumnWidth)
+	b.WriteString("<sheetData>\n")
+	for y := 0; y < m.height; y++ {
+		fmt.Fprintf(&b, `<row r="%d" ht="%d" customHeight="1">`, y+1, xlsxRowHeight)
+		for x := 0; x < m.width; x++ {
+			p := position{x: x, y: y}
+			walls := 0
+			for bit, d := range []direction{north, south, east, west} {
+				if !m.at(p).openings[d] {
+					walls |= 1 << bit
+				}
+			}
+
+			// Cell references use the same lettering as poster rows.
+			ref := fmt.Sprintf("%s%d", rowName(x), y+1)
+			switch p {
+			case m.start:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t>S</t></is></c>`, ref, walls)
+			case m.finish:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t>F</t></is></c>`, ref, walls)
+			default:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"/>`, ref, walls)
+			}
+		}
+		b.WriteString("</row>\n")
+	}
+	b.WriteString("</sheetData>\n")
+	b.WriteString(`<pageMargins left="0.5" right="0.5" top="0.5" bottom="0.5" header="0" footer="0"/>` + "\n")
+	b.WriteString(`<pageSetup fitToWidth="1" fitToHeight="1"/>` + "\n")
+	b.WriteString("</worksheet>")
+	return b.String()
+}
+
+// Render the maze as an XLSX workbook.
+func (m *maze) xlsx() ([]byte, error) {
+	defer tr(ace("rendering xlsx"))
+
+	parts := map[string]string{
+		"xl/styles.xml":            xlsxStyles(),
+		"xl/worksheets/sheet1.xml": m.xlsxSheet(),
+	}
+	for name, part := range xlsxParts {
+		parts[name] = part
+	}
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		w, err := z.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(parts[name])); err != nil {
+			return nil, err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}